@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "tasks" ADD COLUMN "start_at" timestamp;
+ALTER TABLE "tasks" ADD COLUMN "due_at" timestamp;
+
+CREATE INDEX "tasks_account_id_due_at_idx" ON "tasks" ("account_id", "due_at") WHERE "deleted_at" IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "tasks_account_id_due_at_idx";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "due_at";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "start_at";
+-- +goose StatementEnd
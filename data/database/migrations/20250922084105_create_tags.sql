@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "tags" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "account_id" int NOT NULL,
+  "name" varchar(50) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  UNIQUE ("account_id", "name")
+);
+
+CREATE TABLE "task_tags" (
+  "task_id" int NOT NULL,
+  "tag_id" int NOT NULL,
+  PRIMARY KEY ("task_id", "tag_id")
+);
+
+ALTER TABLE "tags" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "task_tags" ADD FOREIGN KEY ("task_id") REFERENCES "tasks" ("id") ON DELETE CASCADE;
+ALTER TABLE "task_tags" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "task_tags_tag_id_idx" ON "task_tags" ("tag_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "task_tags";
+DROP TABLE IF EXISTS "tags";
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "tasks" ADD COLUMN "parent_id" int;
+
+ALTER TABLE "tasks" ADD FOREIGN KEY ("parent_id") REFERENCES "tasks" ("id");
+
+CREATE INDEX "tasks_parent_id_idx" ON "tasks" ("parent_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "tasks_parent_id_idx";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "parent_id";
+-- +goose StatementEnd
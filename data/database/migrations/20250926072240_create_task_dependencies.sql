@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "task_dependencies" (
+  "blocker_id" int NOT NULL,
+  "blocked_id" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  PRIMARY KEY ("blocker_id", "blocked_id"),
+  CHECK ("blocker_id" <> "blocked_id")
+);
+
+ALTER TABLE "task_dependencies" ADD FOREIGN KEY ("blocker_id") REFERENCES "tasks" ("id") ON DELETE CASCADE;
+ALTER TABLE "task_dependencies" ADD FOREIGN KEY ("blocked_id") REFERENCES "tasks" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "task_dependencies_blocked_id_idx" ON "task_dependencies" ("blocked_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "task_dependencies";
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "tasks" ADD COLUMN "completed_at" timestamp;
+ALTER TABLE "tasks" ADD COLUMN "abandoned_at" timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "abandoned_at";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "completed_at";
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- History rows are kept even after their task is permanently deleted,
+-- so task_id intentionally has no foreign key
+CREATE TABLE "task_history" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "task_id" int NOT NULL,
+  "account_id" int NOT NULL,
+  "actor_id" int NOT NULL,
+  "action" varchar(20) NOT NULL,
+  "field" varchar(50),
+  "old_value" varchar,
+  "new_value" varchar,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "task_history" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "task_history" ADD FOREIGN KEY ("actor_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX "task_history_task_id_idx" ON "task_history" ("account_id", "task_id", "created_at");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "task_history";
+-- +goose StatementEnd
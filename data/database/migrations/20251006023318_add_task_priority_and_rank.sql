@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Priorities are stored as numbers so they sort by importance: 1 low, 2 medium, 3 high, 4 urgent
+ALTER TABLE "tasks" ADD COLUMN "priority" smallint NOT NULL DEFAULT 2;
+ALTER TABLE "tasks" ADD COLUMN "rank" varchar COLLATE "C";
+
+-- Existing tasks are ranked by their creation order. A rank must not end with '0'.
+UPDATE "tasks" t SET "rank" = r."rank"
+FROM (
+  SELECT "id", lpad(row_number() OVER (PARTITION BY "account_id" ORDER BY "created_at", "id")::text, 10, '0') || 'V' AS "rank"
+  FROM "tasks"
+) r
+WHERE t."id" = r."id";
+
+ALTER TABLE "tasks" ALTER COLUMN "rank" SET NOT NULL;
+
+CREATE INDEX "tasks_account_id_rank_idx" ON "tasks" ("account_id", "rank") WHERE "deleted_at" IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "tasks_account_id_rank_idx";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "rank";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "priority";
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "projects" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "account_id" int NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "description" varchar,
+  "archived_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+-- Tasks without a project are in the inbox
+ALTER TABLE "tasks" ADD COLUMN "project_id" int;
+
+ALTER TABLE "projects" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "tasks" ADD FOREIGN KEY ("project_id") REFERENCES "projects" ("id") ON DELETE SET NULL;
+
+CREATE INDEX "projects_account_id_idx" ON "projects" ("account_id");
+CREATE INDEX "tasks_project_id_idx" ON "tasks" ("project_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "tasks_project_id_idx";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "project_id";
+DROP TABLE IF EXISTS "projects";
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A series holds the recurrence rule of a recurring task and the
+-- template its next occurrences are created from
+CREATE TABLE "task_series" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "account_id" int NOT NULL,
+  "rule" varchar(500) NOT NULL,
+  "starts_at" timestamp NOT NULL,
+  "project_id" int,
+  "title" varchar(100) NOT NULL,
+  "description" varchar,
+  "priority" smallint NOT NULL DEFAULT 2,
+  "tags" jsonb NOT NULL DEFAULT '[]',
+  "start_offset" bigint,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "tasks" ADD COLUMN "series_id" int;
+ALTER TABLE "tasks" ADD COLUMN "occurrence" int NOT NULL DEFAULT 0;
+
+ALTER TABLE "task_series" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "task_series" ADD FOREIGN KEY ("project_id") REFERENCES "projects" ("id") ON DELETE SET NULL;
+ALTER TABLE "tasks" ADD FOREIGN KEY ("series_id") REFERENCES "task_series" ("id") ON DELETE SET NULL;
+
+-- An occurrence is created only once, even if the previous one is completed again
+CREATE UNIQUE INDEX "tasks_series_id_occurrence_idx" ON "tasks" ("series_id", "occurrence");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS "tasks_series_id_occurrence_idx";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "occurrence";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "series_id";
+DROP TABLE IF EXISTS "task_series";
+-- +goose StatementEnd
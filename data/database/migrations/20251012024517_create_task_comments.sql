@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "task_comments" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "task_id" int NOT NULL,
+  "account_id" int NOT NULL,
+  "author_id" int NOT NULL,
+  "body" text NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "edited_at" timestamp
+);
+
+ALTER TABLE "task_comments" ADD FOREIGN KEY ("task_id") REFERENCES "tasks" ("id") ON DELETE CASCADE;
+ALTER TABLE "task_comments" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "task_comments" ADD FOREIGN KEY ("author_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX "task_comments_task_id_idx" ON "task_comments" ("task_id", "created_at");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "task_comments";
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Attachment contents are stored in the blob storage keyed by their
+-- SHA-256 hash, so attachments with the same content share one blob
+CREATE TABLE "task_attachments" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "task_id" int NOT NULL,
+  "account_id" int NOT NULL,
+  "uploader_id" int NOT NULL,
+  "filename" varchar(255) NOT NULL,
+  "content_type" varchar(255) NOT NULL,
+  "size" bigint NOT NULL,
+  "sha256" char(64) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "task_attachments" ADD FOREIGN KEY ("task_id") REFERENCES "tasks" ("id") ON DELETE CASCADE;
+ALTER TABLE "task_attachments" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "task_attachments" ADD FOREIGN KEY ("uploader_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX "task_attachments_task_id_idx" ON "task_attachments" ("task_id");
+CREATE INDEX "task_attachments_sha256_idx" ON "task_attachments" ("sha256");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "task_attachments";
+-- +goose StatementEnd
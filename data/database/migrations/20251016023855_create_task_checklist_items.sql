@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "task_checklist_items" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "task_id" int NOT NULL,
+  "account_id" int NOT NULL,
+  "text" varchar(500) NOT NULL,
+  "checked" boolean NOT NULL DEFAULT false,
+  -- Items are ordered by position, compared byte by byte
+  "position" varchar COLLATE "C" NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "task_checklist_items" ADD FOREIGN KEY ("task_id") REFERENCES "tasks" ("id") ON DELETE CASCADE;
+ALTER TABLE "task_checklist_items" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX "task_checklist_items_task_id_idx" ON "task_checklist_items" ("task_id", "position");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "task_checklist_items";
+-- +goose StatementEnd
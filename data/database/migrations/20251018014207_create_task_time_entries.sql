@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "tasks" ADD COLUMN "estimate" int;
+
+CREATE TABLE "task_time_entries" (
+  "id" serial PRIMARY KEY NOT NULL,
+  "task_id" int NOT NULL,
+  "account_id" int NOT NULL,
+  "started_at" timestamp NOT NULL,
+  -- A running timer has no end yet
+  "ended_at" timestamp,
+  "note" varchar(500) NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  "updated_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP),
+  CHECK ("ended_at" IS NULL OR "ended_at" >= "started_at")
+);
+
+ALTER TABLE "task_time_entries" ADD FOREIGN KEY ("task_id") REFERENCES "tasks" ("id") ON DELETE CASCADE;
+ALTER TABLE "task_time_entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX "task_time_entries_task_id_idx" ON "task_time_entries" ("task_id", "started_at");
+CREATE INDEX "task_time_entries_account_id_started_at_idx" ON "task_time_entries" ("account_id", "started_at");
+
+-- An account can only have one running timer
+CREATE UNIQUE INDEX "task_time_entries_running_idx" ON "task_time_entries" ("account_id") WHERE "ended_at" IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "task_time_entries";
+ALTER TABLE "tasks" DROP COLUMN IF EXISTS "estimate";
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE "refresh_tokens" (
+  "jti" uuid PRIMARY KEY NOT NULL,
+  -- Tokens rotated from the same login share a family
+  "family_id" uuid NOT NULL,
+  "account_id" int NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp,
+  "revoked_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (CURRENT_TIMESTAMP)
+);
+
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "refresh_tokens_family_id_idx" ON "refresh_tokens" ("family_id");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "refresh_tokens";
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The access token issued along with the refresh token
+ALTER TABLE "refresh_tokens" ADD COLUMN "access_jti" uuid;
+
+CREATE INDEX "refresh_tokens_account_id_idx" ON "refresh_tokens" ("account_id");
+CREATE INDEX "refresh_tokens_expires_at_idx" ON "refresh_tokens" ("expires_at");
+
+CREATE TABLE "revoked_access_tokens" (
+  "jti" uuid PRIMARY KEY NOT NULL,
+  "account_id" int NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "revoked_at" timestamp NOT NULL
+);
+
+ALTER TABLE "revoked_access_tokens" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "revoked_access_tokens_revoked_at_idx" ON "revoked_access_tokens" ("revoked_at");
+CREATE INDEX "revoked_access_tokens_expires_at_idx" ON "revoked_access_tokens" ("expires_at");
+
+-- Access tokens of the account issued before revoked_before are revoked
+CREATE TABLE "account_token_revocations" (
+  "account_id" int PRIMARY KEY NOT NULL,
+  "revoked_before" timestamp NOT NULL,
+  "revoked_at" timestamp NOT NULL
+);
+
+ALTER TABLE "account_token_revocations" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "account_token_revocations_revoked_at_idx" ON "account_token_revocations" ("revoked_at");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "account_token_revocations";
+DROP TABLE IF EXISTS "revoked_access_tokens";
+DROP INDEX IF EXISTS "refresh_tokens_expires_at_idx";
+DROP INDEX IF EXISTS "refresh_tokens_account_id_idx";
+ALTER TABLE "refresh_tokens" DROP COLUMN IF EXISTS "access_jti";
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A session is a login, its ID is the family of its refresh tokens
+CREATE TABLE "auth_sessions" (
+  "id" uuid PRIMARY KEY NOT NULL,
+  "account_id" int NOT NULL,
+  "user_agent" varchar(500) NOT NULL DEFAULT '',
+  "ip_address" varchar(45) NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL,
+  "last_used_at" timestamp NOT NULL,
+  -- The expiry of the latest refresh token of the session
+  "expires_at" timestamp NOT NULL,
+  "revoked_at" timestamp
+);
+
+ALTER TABLE "auth_sessions" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "auth_sessions_account_id_idx" ON "auth_sessions" ("account_id");
+CREATE INDEX "auth_sessions_expires_at_idx" ON "auth_sessions" ("expires_at");
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "auth_sessions";
+-- +goose StatementEnd
@@ -17,6 +17,11 @@ type App struct {
 	cfg     config.Config
 	db      *sqlx.DB
 	httpSrv *http.Server
+	svcs    services
+	logger  *slog.Logger
+
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
 }
 
 func NewApp(cfg config.Config) (*App, error) {
@@ -32,11 +37,19 @@ func NewApp(cfg config.Config) (*App, error) {
 	repos := newRepositories(db, logger)
 
 	// Services
-	svcs := newServices(cfg, repos, logger)
+	svcs, err := newServices(cfg, repos, logger)
+	if err != nil {
+		return nil, err
+	}
 
 	// Register HTTP handlers
 	router := echo.New()
-	registerHandlers(router, logger, svcs)
+	router.IPExtractor, err = newIPExtractor(cfg.HTTPServer)
+	if err != nil {
+		return nil, err
+	}
+
+	registerHandlers(router, cfg, logger, svcs)
 
 	// HTTP server
 	httpSrv := &http.Server{
@@ -44,10 +57,16 @@ func NewApp(cfg config.Config) (*App, error) {
 		Handler: router,
 	}
 
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+
 	return &App{
-		cfg:     cfg,
-		db:      db,
-		httpSrv: httpSrv,
+		cfg:        cfg,
+		db:         db,
+		httpSrv:    httpSrv,
+		svcs:       svcs,
+		logger:     logger,
+		jobsCtx:    jobsCtx,
+		cancelJobs: cancelJobs,
 	}, nil
 }
 
@@ -66,6 +85,8 @@ func (a *App) RunHTTPServer() error {
 }
 
 func (a *App) Shutdown() error {
+	a.cancelJobs()
+
 	if err := a.db.Close(); err != nil {
 		return err
 	}
@@ -12,12 +12,13 @@ func AuthMiddleware(authSvc auth.AuthService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			tokenStr := getTokenFromBearer(c)
-			accId, err := authSvc.ValidateAccessToken(c.Request().Context(), tokenStr)
+			identity, err := authSvc.ValidateAccessToken(c.Request().Context(), tokenStr)
 			if err != nil {
 				return common.ErrorResponse(c, err)
 			}
 
-			c.Set("account_id", accId)
+			c.Set("account_id", identity.AccountID)
+			c.Set("session_id", identity.SessionID)
 
 			return next(c)
 		}
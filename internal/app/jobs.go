@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// RunBackgroundJobs runs the periodic jobs of the app
+// and blocks until the app is shut down
+func (a *App) RunBackgroundJobs() {
+	go a.runPeriodically(time.Duration(a.cfg.JWT.TokenPurgeInterval), a.purgeExpiredTokens)
+	a.runPeriodically(time.Duration(a.cfg.Task.TrashPurgeInterval), a.purgeTrash)
+}
+
+func (a *App) runPeriodically(interval time.Duration, job func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.jobsCtx.Done():
+			return
+
+		case <-ticker.C:
+			job(a.jobsCtx)
+		}
+	}
+}
+
+func (a *App) purgeTrash(ctx context.Context) {
+	n, err := a.svcs.taskSvc.PurgeTrash(ctx, time.Duration(a.cfg.Task.TrashRetention))
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("error on purging deleted tasks: %v", err))
+		return
+	}
+
+	if n != 0 {
+		a.logger.Info("Purged deleted tasks", slog.Int("count", n))
+	}
+}
+
+func (a *App) purgeExpiredTokens(ctx context.Context) {
+	n, err := a.svcs.authSvc.PurgeExpiredTokens(ctx)
+	if err != nil {
+		a.logger.Error(fmt.Sprintf("error on purging expired tokens: %v", err))
+		return
+	}
+
+	if n != 0 {
+		a.logger.Info("Purged expired tokens", slog.Int("count", n))
+	}
+}
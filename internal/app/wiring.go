@@ -1,45 +1,206 @@
 package app
 
 import (
+	"fmt"
 	"log/slog"
+	"net"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/tamboto2000/otaqku-tasks/internal/config"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
 	"github.com/tamboto2000/otaqku-tasks/internal/modules/auth"
 	authHttp "github.com/tamboto2000/otaqku-tasks/internal/modules/auth/http"
+	"github.com/tamboto2000/otaqku-tasks/internal/modules/project"
+	projectHttp "github.com/tamboto2000/otaqku-tasks/internal/modules/project/http"
 	"github.com/tamboto2000/otaqku-tasks/internal/modules/task"
 	taskHttp "github.com/tamboto2000/otaqku-tasks/internal/modules/task/http"
+	"github.com/tamboto2000/otaqku-tasks/pkg/cursor"
+	"github.com/tamboto2000/otaqku-tasks/pkg/keyset"
+	"github.com/tamboto2000/otaqku-tasks/pkg/storage"
 	"github.com/vinovest/sqlx"
 )
 
 type repositories struct {
-	accRepo  auth.AccountRepository
-	taskRepo task.TaskRepository
+	transactor       database.Transactor
+	accRepo          auth.AccountRepository
+	refreshTokenRepo auth.RefreshTokenRepository
+	revocationRepo   auth.RevocationRepository
+	sessionRepo      auth.SessionRepository
+	taskRepo         task.TaskRepository
+	tagRepo          task.TagRepository
+	depRepo          task.DependencyRepository
+	historyRepo      task.HistoryRepository
+	seriesRepo       task.SeriesRepository
+	commentRepo      task.CommentRepository
+	checklistRepo    task.ChecklistRepository
+	projectRepo      project.ProjectRepository
+	attachmentRepo   task.AttachmentRepository
+	timeEntryRepo    task.TimeEntryRepository
 }
 
 func newRepositories(db *sqlx.DB, logger *slog.Logger) repositories {
 	return repositories{
-		accRepo:  auth.NewPostgreAccountRepository(db, logger),
-		taskRepo: task.NewPostgreTaskRepository(db, logger),
+		transactor:       database.NewSQLTransactor(db),
+		accRepo:          auth.NewPostgreAccountRepository(db, logger),
+		refreshTokenRepo: auth.NewPostgreRefreshTokenRepository(db, logger),
+		revocationRepo:   auth.NewPostgreRevocationRepository(db, logger),
+		sessionRepo:      auth.NewPostgreSessionRepository(db, logger),
+		taskRepo:         task.NewPostgreTaskRepository(db, logger),
+		tagRepo:          task.NewPostgreTagRepository(db, logger),
+		depRepo:          task.NewPostgreDependencyRepository(db, logger),
+		historyRepo:      task.NewPostgreHistoryRepository(db, logger),
+		seriesRepo:       task.NewPostgreSeriesRepository(db, logger),
+		commentRepo:      task.NewPostgreCommentRepository(db, logger),
+		checklistRepo:    task.NewPostgreChecklistRepository(db, logger),
+		projectRepo:      project.NewPostgreProjectRepository(db, logger),
+		attachmentRepo:   task.NewPostgreAttachmentRepository(db, logger),
+		timeEntryRepo:    task.NewPostgreTimeEntryRepository(db, logger),
 	}
 }
 
+func newBlobStore(cfg config.Attachment) (storage.BlobStore, error) {
+	switch cfg.Storage {
+	case "local":
+		return storage.NewFilesystemStore(cfg.LocalDir)
+
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}, nil)
+	}
+
+	return nil, fmt.Errorf("unknown attachment storage %q", cfg.Storage)
+}
+
+// newIPExtractor only trusts the X-Forwarded-For header set by the trusted proxies,
+// as clients can send any value in it
+func newIPExtractor(cfg config.HTTPServer) (echo.IPExtractor, error) {
+	if len(cfg.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, cidr := range cfg.TrustedProxies {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy range %q: %v", cidr, err)
+		}
+
+		opts = append(opts, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(opts...), nil
+}
+
+func newKeySet(cfg config.JWT) (keyset.Set, error) {
+	if cfg.KeyDir == "" {
+		return keyset.NewSet([]keyset.Key{keyset.NewHMACKey(auth.HMACKeyID, cfg.SigningKey.Decoded)}, auth.HMACKeyID)
+	}
+
+	keys, err := keyset.LoadDir(cfg.KeyDir)
+	if err != nil {
+		return keyset.Set{}, err
+	}
+
+	// The signing key keeps verifying the tokens it signed before
+	// the switch to the key directory
+	if len(cfg.SigningKey.Decoded) != 0 {
+		keys = append(keys, keyset.NewHMACVerificationKey(auth.HMACKeyID, cfg.SigningKey.Decoded))
+	}
+
+	return keyset.NewSet(keys, cfg.ActiveKeyID)
+}
+
 type services struct {
-	authSvc auth.AuthService
-	taskSvc task.TaskService
+	authSvc       auth.AuthService
+	taskSvc       task.TaskService
+	tagSvc        task.TagService
+	projectSvc    project.ProjectService
+	attachmentSvc task.AttachmentService
+	timeSvc       task.TimeService
 }
 
-func newServices(cfg config.Config, repos repositories, logger *slog.Logger) services {
-	return services{
-		authSvc: auth.NewAuthService(cfg.JWT, repos.accRepo, logger),
-		taskSvc: task.NewTaskService(repos.taskRepo),
+func newServices(cfg config.Config, repos repositories, logger *slog.Logger) (services, error) {
+	transitions, err := task.NewTransitionTable(cfg.Task.StatusTransitions)
+	if err != nil {
+		return services{}, err
+	}
+
+	blobs, err := newBlobStore(cfg.Attachment)
+	if err != nil {
+		return services{}, err
 	}
+
+	contents := task.NewContentCollector(repos.attachmentRepo, blobs, repos.transactor, logger)
+
+	taskSvc := task.NewTaskService(
+		repos.taskRepo,
+		repos.tagRepo,
+		repos.depRepo,
+		repos.historyRepo,
+		repos.seriesRepo,
+		repos.commentRepo,
+		repos.checklistRepo,
+		contents,
+		repos.transactor,
+		cursor.NewSigner(cfg.Pagination.CursorSigningKey.Decoded),
+		transitions,
+		repos.projectRepo,
+	)
+
+	revocations := auth.NewRevocationStore(
+		repos.revocationRepo,
+		time.Duration(cfg.JWT.AccessTokenDuration),
+		time.Duration(cfg.JWT.Leeway),
+		time.Duration(cfg.JWT.RevocationSyncInterval),
+	)
+
+	keys, err := newKeySet(cfg.JWT)
+	if err != nil {
+		return services{}, err
+	}
+
+	attachmentLimits := task.AttachmentLimits{
+		MaxSize:      cfg.Attachment.MaxSize << 20,
+		AllowedTypes: cfg.Attachment.AllowedTypes,
+	}
+
+	return services{
+		authSvc:       auth.NewAuthService(cfg.JWT, keys, repos.accRepo, repos.refreshTokenRepo, repos.sessionRepo, revocations, repos.transactor, logger),
+		taskSvc:       taskSvc,
+		tagSvc:        task.NewTagService(repos.tagRepo),
+		projectSvc:    project.NewProjectService(repos.projectRepo, taskSvc, repos.transactor),
+		attachmentSvc: task.NewAttachmentService(repos.attachmentRepo, repos.taskRepo, blobs, contents, repos.transactor, attachmentLimits),
+		timeSvc:       task.NewTimeService(repos.timeEntryRepo, repos.taskRepo, repos.transactor),
+	}, nil
 }
 
-func registerHandlers(router *echo.Echo, logger *slog.Logger, svcs services) {
-	authHandler := authHttp.NewAuthHandler(svcs.authSvc)
+func registerHandlers(router *echo.Echo, cfg config.Config, logger *slog.Logger, svcs services) {
+	authHandler := authHttp.NewAuthHandler(svcs.authSvc, logger, AuthMiddleware(svcs.authSvc))
 	authHttp.RegisterAuthHandler(authHandler, router)
 
-	taskHandler := taskHttp.NewTaskHandler(svcs.taskSvc, logger, AuthMiddleware(svcs.authSvc))
+	taskHandler := taskHttp.NewTaskHandler(svcs.taskSvc, logger, AuthMiddleware(svcs.authSvc), cfg.Task.RequireIfMatch)
 	taskHttp.RegisterTaskHandler(taskHandler, router)
+
+	tagHandler := taskHttp.NewTagHandler(svcs.tagSvc, logger, AuthMiddleware(svcs.authSvc))
+	taskHttp.RegisterTagHandler(tagHandler, router)
+
+	projectHandler := projectHttp.NewProjectHandler(svcs.projectSvc, logger, AuthMiddleware(svcs.authSvc))
+	projectHttp.RegisterProjectHandler(projectHandler, router)
+
+	attachmentHandler := taskHttp.NewAttachmentHandler(svcs.attachmentSvc, logger, AuthMiddleware(svcs.authSvc), cfg.Attachment.MaxSize<<20)
+	taskHttp.RegisterAttachmentHandler(attachmentHandler, router)
+
+	timeHandler := taskHttp.NewTimeHandler(svcs.timeSvc, logger, AuthMiddleware(svcs.authSvc))
+	taskHttp.RegisterTimeHandler(timeHandler, router)
 }
@@ -15,3 +15,13 @@ func AccountIDFromEchoCtx(ectx echo.Context) (int, error) {
 
 	return accId, nil
 }
+
+func SessionIDFromEchoCtx(ectx echo.Context) (string, error) {
+	sessionIdVal := ectx.Get("session_id")
+	sessionId, ok := sessionIdVal.(string)
+	if !ok {
+		return "", errors.New("session id is not string")
+	}
+
+	return sessionId, nil
+}
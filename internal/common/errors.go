@@ -1,15 +1,30 @@
 package common
 
+import "database/sql"
+
 type Error struct {
 	Code    string       `json:"code"`
 	Message string       `json:"message"`
 	Fields  []FieldError `json:"fields,omitempty"`
+	Details any          `json:"details,omitempty"`
 }
 
 func (err Error) Error() string {
 	return err.Message
 }
 
+// Is reports whether target is an Error with the same code and message.
+// Error is not comparable because of its slice fields,
+// so errors.Is can not match it without this method.
+func (err Error) Is(target error) bool {
+	t, ok := target.(Error)
+	if !ok {
+		return false
+	}
+
+	return err.Code == t.Code && err.Message == t.Message
+}
+
 type FieldError struct {
 	Name     string   `json:"name"`
 	Messages []string `json:"messages"`
@@ -21,6 +36,18 @@ const (
 	ErrCodeInputValidation = "input_validation"
 	ErrCodeAlreadyExists   = "already_exists"
 	ErrCodeUnauthorized    = "unauthorized"
+	ErrCodeConflict        = "conflict"
+
+	ErrCodePayloadTooLarge      = "payload_too_large"
+	ErrCodeUnsupportedMediaType = "unsupported_media_type"
+
+	ErrCodePreconditionFailed   = "precondition_failed"
+	ErrCodePreconditionRequired = "precondition_required"
+
+	ErrCodeInvalidStatusTransition = "invalid_status_transition"
+
+	ErrCodeUnprocessable = "unprocessable"
+	ErrCodeInternal      = "internal"
 )
 
 // Common errors
@@ -29,4 +56,25 @@ var (
 		Code:    ErrCodeNotFound,
 		Message: "Not found",
 	}
+	// ErrInternal reports an unexpected failure where
+	// a result is expected instead of a response
+	ErrInternal = Error{
+		Code:    ErrCodeInternal,
+		Message: "Internal server error",
+	}
 )
+
+// NotFoundIfNoRowsAffected returns ErrNotFound
+// if the statement did not match any row
+func NotFoundIfNoRowsAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -33,6 +33,15 @@ func InvalidQueryParamResponse(ectx echo.Context, err error) error {
 	return ectx.JSON(http.StatusBadRequest, resp)
 }
 
+func UnsupportedMediaTypeResponse(ectx echo.Context, err error) error {
+	resp := HTTPResponse{
+		Message: "Unsupported media type",
+		Error:   Error{Message: err.Error()},
+	}
+
+	return ectx.JSON(http.StatusUnsupportedMediaType, resp)
+}
+
 func ErrorResponse(ectx echo.Context, err error) error {
 	httpCode := http.StatusInternalServerError
 	resp := HTTPResponse{Message: "Internal server error"}
@@ -51,6 +60,24 @@ func ErrorResponse(ectx echo.Context, err error) error {
 
 		case ErrCodeUnauthorized:
 			httpCode = http.StatusUnauthorized
+
+		case ErrCodeConflict, ErrCodeInvalidStatusTransition:
+			httpCode = http.StatusConflict
+
+		case ErrCodePreconditionFailed:
+			httpCode = http.StatusPreconditionFailed
+
+		case ErrCodePreconditionRequired:
+			httpCode = http.StatusPreconditionRequired
+
+		case ErrCodeUnprocessable:
+			httpCode = http.StatusUnprocessableEntity
+
+		case ErrCodePayloadTooLarge:
+			httpCode = http.StatusRequestEntityTooLarge
+
+		case ErrCodeUnsupportedMediaType:
+			httpCode = http.StatusUnsupportedMediaType
 		}
 
 		resp.Message = xErr.Message
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"github.com/tamboto2000/otaqku-tasks/pkg/config"
+	"github.com/tamboto2000/otaqku-tasks/pkg/cursor"
 )
 
 type Database struct {
@@ -19,6 +21,9 @@ type Database struct {
 
 type HTTPServer struct {
 	Port string `env:"HTTP_SERVER_PORT"`
+	// TrustedProxies are the CIDR ranges of the proxies whose X-Forwarded-For
+	// header is trusted for the client IP. The connection address is used when empty.
+	TrustedProxies config.StringSlice `env:"HTTP_SERVER_TRUSTED_PROXIES"`
 }
 
 type Logging struct {
@@ -30,6 +35,57 @@ type JWT struct {
 	AccessTokenDuration  config.MinuteDuration   `env:"JWT_ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration config.MinuteDuration   `env:"JWT_REFRESH_TOKEN_DURATION"`
 	SigningKey           config.RawBase64Encoded `env:"JWT_SIGNING_KEY"`
+	// KeyDir holds the PEM encoded RSA, P-256 or Ed25519 keys tokens are signed
+	// and verified with, each named after its kid as <kid>.pem.
+	// Tokens are signed with SigningKey using HS256 when it is empty,
+	// otherwise SigningKey, if set, only verifies the tokens it signed before.
+	KeyDir string `env:"JWT_KEY_DIR"`
+	// ActiveKeyID is the kid of the key new tokens are signed with,
+	// the other keys only verify the tokens signed before a rotation
+	ActiveKeyID string `env:"JWT_ACTIVE_KEY_ID"`
+	// Issuer and Audience are the iss and aud claims of issued tokens,
+	// tokens issued by or for anyone else are rejected. Empty values are not checked.
+	Issuer   string `env:"JWT_ISSUER" default:"otaqku-tasks"`
+	Audience string `env:"JWT_AUDIENCE" default:"otaqku-tasks"`
+	// Leeway is the clock skew between hosts tolerated when checking
+	// the exp, nbf and iat claims
+	Leeway config.SecondDuration `env:"JWT_LEEWAY" default:"30"`
+	// RevocationSyncInterval is how often revoked tokens are loaded from the database,
+	// which is how long a revocation takes to reach the other instances
+	RevocationSyncInterval config.SecondDuration `env:"JWT_REVOCATION_SYNC_INTERVAL" default:"10"`
+	// TokenPurgeInterval is how often the records of expired tokens are deleted
+	TokenPurgeInterval config.MinuteDuration `env:"JWT_TOKEN_PURGE_INTERVAL" default:"60"`
+}
+
+type Pagination struct {
+	CursorSigningKey config.RawBase64Encoded `env:"PAGINATION_CURSOR_SIGNING_KEY"`
+}
+
+type Task struct {
+	// StatusTransitions overrides the allowed task status transitions,
+	// formatted as status:next1,next2;status2:next3
+	StatusTransitions config.StringSliceMap `env:"TASK_STATUS_TRANSITIONS"`
+	// TrashRetention is how long deleted tasks are kept before being purged
+	TrashRetention     config.HourDuration   `env:"TASK_TRASH_RETENTION" default:"720"`
+	TrashPurgeInterval config.MinuteDuration `env:"TASK_TRASH_PURGE_INTERVAL" default:"60"`
+	// RequireIfMatch rejects task changes sent without an If-Match header
+	RequireIfMatch bool `env:"TASK_REQUIRE_IF_MATCH"`
+}
+
+type Attachment struct {
+	// Storage is where attachment contents are stored, local or s3
+	Storage  string `env:"ATTACHMENT_STORAGE" default:"local"`
+	LocalDir string `env:"ATTACHMENT_LOCAL_DIR" default:"./data/attachments"`
+	// S3Endpoint is the base URL of an S3 compatible storage
+	S3Endpoint  string `env:"ATTACHMENT_S3_ENDPOINT"`
+	S3Region    string `env:"ATTACHMENT_S3_REGION" default:"us-east-1"`
+	S3Bucket    string `env:"ATTACHMENT_S3_BUCKET"`
+	S3AccessKey string `env:"ATTACHMENT_S3_ACCESS_KEY"`
+	S3SecretKey string `env:"ATTACHMENT_S3_SECRET_KEY"`
+	// MaxSize is the maximum size of an attachment in megabytes
+	MaxSize int64 `env:"ATTACHMENT_MAX_SIZE" default:"10"`
+	// AllowedTypes are the accepted media types, type/* accepts all subtypes
+	AllowedTypes config.StringSlice `env:"ATTACHMENT_ALLOWED_TYPES" default:"image/*,text/plain,application/pdf,application/json,application/zip"`
 }
 
 type Config struct {
@@ -37,6 +93,9 @@ type Config struct {
 	HTTPServer HTTPServer
 	Logging    Logging
 	JWT        JWT
+	Pagination Pagination
+	Task       Task
+	Attachment Attachment
 }
 
 func LoadConfig() (Config, error) {
@@ -45,5 +104,35 @@ func LoadConfig() (Config, error) {
 		return Config{}, fmt.Errorf("loading config error: %v", err)
 	}
 
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %v", err)
+	}
+
 	return cfg, nil
 }
+
+func (cfg Config) validate() error {
+	if cfg.JWT.Leeway < 0 {
+		return errors.New("JWT_LEEWAY must not be negative")
+	}
+
+	if cfg.JWT.RevocationSyncInterval <= 0 {
+		return errors.New("JWT_REVOCATION_SYNC_INTERVAL must be positive")
+	}
+
+	// The intervals of the background jobs
+	if cfg.JWT.TokenPurgeInterval <= 0 {
+		return errors.New("JWT_TOKEN_PURGE_INTERVAL must be positive")
+	}
+
+	if cfg.Task.TrashPurgeInterval <= 0 {
+		return errors.New("TASK_TRASH_PURGE_INTERVAL must be positive")
+	}
+
+	// Cursors signed with an empty or short key can be forged
+	if len(cfg.Pagination.CursorSigningKey.Decoded) < cursor.MinKeySize {
+		return fmt.Errorf("PAGINATION_CURSOR_SIGNING_KEY must be at least %d bytes", cursor.MinKeySize)
+	}
+
+	return nil
+}
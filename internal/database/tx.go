@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vinovest/sqlx"
+)
+
+type txKey struct{}
+
+// txState is the transaction carried by a context,
+// along with the functions to run once it commits
+type txState struct {
+	tx          *sqlx.Tx
+	afterCommit []func(ctx context.Context)
+}
+
+// Transactor runs a function inside a database transaction
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type SQLTransactor struct {
+	db *sqlx.DB
+}
+
+func NewSQLTransactor(db *sqlx.DB) SQLTransactor {
+	return SQLTransactor{db: db}
+}
+
+// WithinTx begins a transaction and carries it in the context passed to fn.
+// The transaction is committed if fn returns nil and rolled back otherwise.
+// If ctx already carries a transaction, fn joins it instead of
+// beginning a new one.
+func (t SQLTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error on beginning transaction: %v", err)
+	}
+
+	state := &txState{tx: tx}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("error on committing transaction: %v", commitErr)
+			return
+		}
+
+		for _, afterFn := range state.afterCommit {
+			afterFn(ctx)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey{}, state))
+}
+
+// AfterCommit runs fn once the transaction carried by ctx has committed,
+// fn is not run if it is rolled back. Without a transaction, fn runs right away.
+// Used for the side effects that can not be rolled back, such as deleting files.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+
+	fn(ctx)
+}
+
+// Conn returns the transaction carried by ctx, or db if there is none.
+// Repositories should run their queries through Conn so they take part
+// in the transaction started by Transactor.WithinTx.
+func Conn(ctx context.Context, db *sqlx.DB) sqlx.Queryable {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return state.tx
+	}
+
+	return db
+}
@@ -26,3 +26,19 @@ type LoginRequest struct {
 type ExchangeRefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	// All signs out of every session of the account
+	All bool `json:"all"`
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Current is true for the session of the request
+	Current bool `json:"current"`
+}
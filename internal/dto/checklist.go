@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+type CreateChecklistItemRequest struct {
+	Text string `json:"text"`
+}
+
+type MoveChecklistItemRequest struct {
+	BeforeID *int `json:"before_id"`
+	AfterID  *int `json:"after_id"`
+}
+
+type ChecklistItem struct {
+	ID        int       `json:"id"`
+	TaskID    int       `json:"task_id"`
+	Text      string    `json:"text"`
+	Checked   bool      `json:"checked"`
+	Position  string    `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
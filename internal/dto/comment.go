@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+type CreateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// Comment is a comment on a task, Body is Markdown
+// and is returned as written for clients to render
+type Comment struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	AuthorID  int        `json:"author_id"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+type CommentList struct {
+	Comments   []Comment          `json:"comments"`
+	Pagination PaginationMetadata `json:"pagination"`
+}
@@ -1,11 +1,16 @@
 package dto
 
 type Pagination struct {
-	Page     int `json:"page" query:"page"`
+	Page     int `json:"page,omitempty" query:"page"`
 	PageSize int `json:"page_size" query:"page_size"`
+	// Cursor switches the list into cursor pagination mode.
+	// An empty cursor requests the first page.
+	Cursor *string `json:"-" query:"cursor"`
 }
 
 type PaginationMetadata struct {
 	Pagination
-	Total int `json:"total"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+type CreateProjectRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type Project struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Archived    bool       `json:"archived"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type ProjectFilter struct {
+	Archived bool `query:"archived"`
+}
+
+type ProjectList struct {
+	Projects   []Project          `json:"projects"`
+	Pagination PaginationMetadata `json:"pagination"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type CreateTagRequest struct {
+	Name string `json:"name"`
+}
+
+type Tag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TagList struct {
+	Tags []Tag `json:"tags"`
+}
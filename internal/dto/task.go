@@ -1,22 +1,140 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+)
 
 type CreateTaskRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	ParentID        *int       `json:"parent_id"`
+	ProjectID       *int       `json:"project_id"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	Priority        string     `json:"priority"`
+	StartAt         *time.Time `json:"start_at"`
+	DueAt           *time.Time `json:"due_at"`
+	EstimateSeconds *int       `json:"estimate_seconds"`
+	Tags            []string   `json:"tags"`
+	Recurrence      string     `json:"recurrence"`
 }
 
 type Task struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description,omitempty"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              int              `json:"id"`
+	ParentID        *int             `json:"parent_id,omitempty"`
+	ProjectID       *int             `json:"project_id,omitempty"`
+	Title           string           `json:"title"`
+	Description     string           `json:"description,omitempty"`
+	Status          string           `json:"status"`
+	Priority        string           `json:"priority"`
+	Rank            string           `json:"rank"`
+	StartAt         *time.Time       `json:"start_at,omitempty"`
+	DueAt           *time.Time       `json:"due_at,omitempty"`
+	EstimateSeconds *int             `json:"estimate_seconds,omitempty"`
+	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
+	AbandonedAt     *time.Time       `json:"abandoned_at,omitempty"`
+	Overdue         bool             `json:"overdue"`
+	Tags            []string         `json:"tags,omitempty"`
+	Subtasks        *SubtaskProgress `json:"subtasks,omitempty"`
+	Recurrence      string           `json:"recurrence,omitempty"`
+	SeriesID        *int             `json:"series_id,omitempty"`
+	Occurrence      int              `json:"occurrence,omitempty"`
+	CommentCount    int              `json:"comment_count"`
+	ChecklistDone   int              `json:"checklist_done"`
+	ChecklistTotal  int              `json:"checklist_total"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	Version         int              `json:"version"`
+	DeletedAt       *time.Time       `json:"deleted_at,omitempty"`
+	// Scope is the series edit scope of an update, this or future.
+	// It is given separately from the task representation.
+	Scope string `json:"-"`
+}
+
+type SubtaskProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type MoveTaskParentRequest struct {
+	ParentID *int `json:"parent_id"`
+}
+
+type MoveTaskRequest struct {
+	BeforeID *int    `json:"before_id"`
+	AfterID  *int    `json:"after_id"`
+	Status   *string `json:"status"`
+}
+
+type AddDependencyRequest struct {
+	BlockerID int `json:"blocker_id"`
+}
+
+type TaskDependencies struct {
+	BlockedBy []Task `json:"blocked_by"`
+	Blocking  []Task `json:"blocking"`
+}
+
+type TaskFilter struct {
+	ParentID      *int       `query:"parent_id"`
+	ProjectID     *int       `query:"project_id"`
+	Inbox         bool       `query:"inbox"`
+	Status        []string   `query:"status"`
+	Query         string     `query:"q"`
+	Tags          []string   `query:"tag"`
+	TagMode       string     `query:"tag_mode"`
+	Overdue       bool       `query:"overdue"`
+	DueBefore     *time.Time `query:"due_before"`
+	DueAfter      *time.Time `query:"due_after"`
+	CreatedBefore *time.Time `query:"created_before"`
+	CreatedAfter  *time.Time `query:"created_after"`
+	UpdatedBefore *time.Time `query:"updated_before"`
+	UpdatedAfter  *time.Time `query:"updated_after"`
+	Sort          string     `query:"sort"`
 }
 
 type TaskList struct {
 	Tasks      []Task             `json:"tasks"`
 	Pagination PaginationMetadata `json:"pagination"`
 }
+
+type TaskHistoryEntry struct {
+	ID        int       `json:"id"`
+	ActorID   int       `json:"actor_id"`
+	Action    string    `json:"action"`
+	Field     *string   `json:"field,omitempty"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TaskHistory struct {
+	Entries    []TaskHistoryEntry `json:"entries"`
+	Pagination PaginationMetadata `json:"pagination"`
+}
+
+type BatchTaskRequest struct {
+	Mode       string               `json:"mode"`
+	Operations []BatchTaskOperation `json:"operations"`
+}
+
+type BatchTaskOperation struct {
+	Op      string             `json:"op"`
+	ID      int                `json:"id,omitempty"`
+	Version int                `json:"version,omitempty"`
+	Create  *CreateTaskRequest `json:"create,omitempty"`
+	Update  *Task              `json:"update,omitempty"`
+	Scope   string             `json:"scope,omitempty"`
+}
+
+type BatchTaskResult struct {
+	Index  int           `json:"index"`
+	Status string        `json:"status"`
+	Task   *Task         `json:"task,omitempty"`
+	Error  *common.Error `json:"error,omitempty"`
+}
+
+type BatchTaskResponse struct {
+	Mode    string            `json:"mode"`
+	Results []BatchTaskResult `json:"results"`
+}
@@ -0,0 +1,59 @@
+package dto
+
+import "time"
+
+type StartTimerRequest struct {
+	Note string `json:"note"`
+}
+
+// CreateTimeEntryRequest records time spent without running a timer.
+// StartedAt defaults to the duration before now.
+type CreateTimeEntryRequest struct {
+	StartedAt       *time.Time `json:"started_at"`
+	DurationSeconds int        `json:"duration_seconds"`
+	Note            string     `json:"note"`
+}
+
+type TimeEntry struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	// DurationSeconds of a running timer is the time elapsed so far
+	DurationSeconds int       `json:"duration_seconds"`
+	Running         bool      `json:"running"`
+	Note            string    `json:"note,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type TimeEntryList struct {
+	Entries    []TimeEntry        `json:"entries"`
+	Pagination PaginationMetadata `json:"pagination"`
+}
+
+type TimeReportQuery struct {
+	From    *time.Time `query:"from"`
+	To      *time.Time `query:"to"`
+	GroupBy string     `query:"group_by"`
+}
+
+type TimeReport struct {
+	From         time.Time         `json:"from"`
+	To           time.Time         `json:"to"`
+	GroupBy      string            `json:"group_by"`
+	TotalSeconds int               `json:"total_seconds"`
+	Groups       []TimeReportGroup `json:"groups"`
+}
+
+// TimeReportGroup is the time tracked for a task, a day (YYYY-MM-DD in UTC)
+// or a task status, depending on how the report is grouped.
+// TaskID, Title and EstimateSeconds are only set when grouped by task.
+type TimeReportGroup struct {
+	Key             string `json:"key"`
+	TaskID          *int   `json:"task_id,omitempty"`
+	Title           string `json:"title,omitempty"`
+	EstimateSeconds *int   `json:"estimate_seconds,omitempty"`
+	TotalSeconds    int    `json:"total_seconds"`
+	EntryCount      int    `json:"entry_count"`
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/tamboto2000/otaqku-tasks/internal/common"
 	"github.com/tamboto2000/otaqku-tasks/internal/config"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
 	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/pkg/keyset"
 )
 
 var (
@@ -31,19 +33,54 @@ const (
 	jwtScopeRefresh = "refresh"
 )
 
+// HMACKeyID is the kid of JWT_SIGNING_KEY, which signed the tokens
+// issued before key ids were added
+const HMACKeyID = "default"
+
 type jwtClaims struct {
 	jwt.RegisteredClaims
 	Scope string `json:"scope"`
+	// SessionID is the session the token was issued in
+	SessionID string `json:"sid,omitempty"`
+}
+
+// Identity is who an access token was issued to
+type Identity struct {
+	AccountID int
+	SessionID string
 }
 
 type AuthService struct {
-	jwtCfg  config.JWT
-	accRepo AccountRepository
-	logger  *slog.Logger
+	jwtCfg           config.JWT
+	keys             keyset.Set
+	accRepo          AccountRepository
+	refreshTokenRepo RefreshTokenRepository
+	sessionRepo      SessionRepository
+	revocations      *RevocationStore
+	transactor       database.Transactor
+	logger           *slog.Logger
 }
 
-func NewAuthService(jwtCfg config.JWT, accRepo AccountRepository, logger *slog.Logger) AuthService {
-	return AuthService{jwtCfg: jwtCfg, accRepo: accRepo, logger: logger}
+func NewAuthService(
+	jwtCfg config.JWT,
+	keys keyset.Set,
+	accRepo AccountRepository,
+	refreshTokenRepo RefreshTokenRepository,
+	sessionRepo SessionRepository,
+	revocations *RevocationStore,
+	transactor database.Transactor,
+	logger *slog.Logger,
+) AuthService {
+	return AuthService{
+		jwtCfg:           jwtCfg,
+		keys:             keys,
+		accRepo:          accRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		revocations:      revocations,
+		transactor:       transactor,
+		logger:           logger,
+	}
 }
 
 func (svc AuthService) RegisterAccount(ctx context.Context, req dto.CreateAccountRequest) error {
@@ -65,7 +102,7 @@ func (svc AuthService) RegisterAccount(ctx context.Context, req dto.CreateAccoun
 	return err
 }
 
-func (svc AuthService) Login(ctx context.Context, email, pwd string) (dto.TokenResponse, error) {
+func (svc AuthService) Login(ctx context.Context, email, pwd string, meta SessionMetadata) (dto.TokenResponse, error) {
 	acc, err := svc.accRepo.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, common.ErrNotFound) {
@@ -79,59 +116,252 @@ func (svc AuthService) Login(ctx context.Context, email, pwd string) (dto.TokenR
 		return dto.TokenResponse{}, ErrInvalidCredentials
 	}
 
-	return svc.buildTokenPair(time.Now(), acc.ID)
+	// Every login starts a new session, which is a new refresh token family
+	sessionId, err := uuid.NewV7()
+	if err != nil {
+		svc.logger.Error(fmt.Sprintf("error on generating session ID: %v", err))
+		return dto.TokenResponse{}, err
+	}
+
+	var tokens dto.TokenResponse
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		tokens, err = svc.issueTokenPair(ctx, now, acc.ID, sessionId.String())
+		if err != nil {
+			return err
+		}
+
+		session := newSession(sessionId.String(), acc.ID, meta, now, tokens.RefreshToken.ExpiresAt)
+
+		return svc.sessionRepo.Save(ctx, session)
+	})
+
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	return tokens, nil
 }
 
-func (svc AuthService) buildJwt(reqTime time.Time, d time.Duration, userId int, scope string) (dto.Token, error) {
+// buildJwt returns the signed token along with its JTI
+func (svc AuthService) buildJwt(reqTime time.Time, d time.Duration, userId int, sessionId, scope string) (dto.Token, string, error) {
 	jti, err := uuid.NewV7()
 	if err != nil {
 		svc.logger.Error(fmt.Sprintf("error on generating token JTI: %v", err))
-		return dto.Token{}, err
+		return dto.Token{}, "", err
 	}
 
 	expiresAt := reqTime.Add(d)
 	claims := jwtClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ID: jti.String(),
-			// TODO: Add Issuer
+			ID:        jti.String(),
+			Issuer:    svc.jwtCfg.Issuer,
 			Subject:   strconv.Itoa(userId),
 			IssuedAt:  jwt.NewNumericDate(reqTime),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			NotBefore: jwt.NewNumericDate(reqTime),
-			// TODO: Add Audience
 		},
-		Scope: scope,
+		Scope:     scope,
+		SessionID: sessionId,
+	}
+
+	if svc.jwtCfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{svc.jwtCfg.Audience}
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString(svc.jwtCfg.SigningKey.Decoded)
+	key := svc.keys.Active()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	tokenStr, err := token.SignedString(key.SigningKey())
 	if err != nil {
 		svc.logger.Error(fmt.Sprintf("error on signing token: %v", err))
-		return dto.Token{}, err
+		return dto.Token{}, "", err
 	}
 
 	return dto.Token{
 		Token:     tokenStr,
 		ExpiresAt: expiresAt,
-	}, nil
+	}, jti.String(), nil
 }
 
-func (svc AuthService) ExchangeRefreshToken(ctx context.Context, tokenStr string) (dto.TokenResponse, error) {
-	userId, err := svc.validateToken(tokenStr, jwtScopeRefresh)
+// ExchangeRefreshToken rotates a refresh token, which can only be exchanged once.
+// Presenting a token that was already exchanged means it was stolen, either
+// the thief or the user used it first, so the whole family is revoked.
+// The session of a family logged in before sessions were recorded
+// is created with the metadata of the client refreshing it.
+func (svc AuthService) ExchangeRefreshToken(ctx context.Context, tokenStr string, meta SessionMetadata) (dto.TokenResponse, error) {
+	userId, claims, err := svc.validateToken(tokenStr, jwtScopeRefresh)
 	if err != nil {
 		return dto.TokenResponse{}, err
 	}
 
-	return svc.buildTokenPair(time.Now(), userId)
+	var tokens dto.TokenResponse
+	var reused bool
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		token, err := svc.refreshTokenRepo.GetByJTIForUpdate(ctx, claims.ID)
+		if err != nil {
+			if errors.Is(err, common.ErrNotFound) {
+				return ErrInvalidToken
+			}
+
+			return err
+		}
+
+		if token.RevokedAt != nil {
+			return ErrInvalidToken
+		}
+
+		now := time.Now()
+		if token.UsedAt != nil {
+			// The revocation is committed, the token is rejected afterwards
+			reused = true
+			return svc.revokeFamily(ctx, token.FamilyID, now)
+		}
+
+		if err := svc.refreshTokenRepo.MarkUsedByJTI(ctx, token.JTI, now); err != nil {
+			return err
+		}
+
+		tokens, err = svc.issueTokenPair(ctx, now, userId, token.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		err = svc.sessionRepo.TouchByID(ctx, token.FamilyID, now, tokens.RefreshToken.ExpiresAt)
+		if errors.Is(err, common.ErrNotFound) {
+			session := newSession(token.FamilyID, userId, meta, now, tokens.RefreshToken.ExpiresAt)
+			return svc.sessionRepo.Save(ctx, session)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	if reused {
+		svc.logger.Warn("Refresh token reuse detected, token family revoked", slog.Int("account_id", userId))
+		return dto.TokenResponse{}, ErrInvalidToken
+	}
+
+	return tokens, nil
 }
 
-func (svc AuthService) buildTokenPair(reqTime time.Time, userId int) (dto.TokenResponse, error) {
-	accessToken, err := svc.buildJwt(reqTime, time.Duration(svc.jwtCfg.AccessTokenDuration), userId, jwtScopeAccess)
+// Logout revokes the refresh token family the token belongs to along with
+// its access tokens, or every token of the account if all is true
+func (svc AuthService) Logout(ctx context.Context, tokenStr string, all bool) error {
+	userId, claims, err := svc.validateToken(tokenStr, jwtScopeRefresh)
+	if err != nil {
+		return err
+	}
+
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		token, err := svc.refreshTokenRepo.GetByJTIForUpdate(ctx, claims.ID)
+		if err != nil {
+			if errors.Is(err, common.ErrNotFound) {
+				return ErrInvalidToken
+			}
+
+			return err
+		}
+
+		if all {
+			return svc.revokeAccount(ctx, userId, time.Now())
+		}
+
+		return svc.revokeFamily(ctx, token.FamilyID, time.Now())
+	})
+}
+
+// revokeFamily ends the session of a refresh token family, revoking its refresh
+// tokens and the access tokens issued with them that are still valid
+func (svc AuthService) revokeFamily(ctx context.Context, familyId string, now time.Time) error {
+	if err := svc.sessionRepo.RevokeByID(ctx, familyId, now); err != nil {
+		return err
+	}
+
+	if err := svc.refreshTokenRepo.RevokeByFamilyID(ctx, familyId, now); err != nil {
+		return err
+	}
+
+	lifetime := time.Duration(svc.jwtCfg.AccessTokenDuration)
+	tokens, err := svc.refreshTokenRepo.GetByFamilyIDCreatedAfter(ctx, familyId, now.Add(-lifetime))
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		if token.AccessJTI == nil {
+			continue
+		}
+
+		if err := svc.revocations.RevokeToken(ctx, *token.AccessJTI, token.AccountID, token.CreatedAt.Add(lifetime)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeAccount ends every session of an account, revoking its
+// refresh tokens and the access tokens issued until now
+func (svc AuthService) revokeAccount(ctx context.Context, accId int, now time.Time) error {
+	if err := svc.sessionRepo.RevokeByAccountID(ctx, accId, now); err != nil {
+		return err
+	}
+
+	if err := svc.refreshTokenRepo.RevokeByAccountID(ctx, accId, now); err != nil {
+		return err
+	}
+
+	return svc.revocations.RevokeIssuedBefore(ctx, accId, now)
+}
+
+// PurgeExpiredTokens deletes the records of expired sessions and refresh
+// tokens, and of revoked access tokens that have expired
+func (svc AuthService) PurgeExpiredTokens(ctx context.Context) (int, error) {
+	now := time.Now()
+	sessions, err := svc.sessionRepo.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	refreshTokens, err := svc.refreshTokenRepo.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	accessTokens, err := svc.revocations.PurgeExpired(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return sessions + refreshTokens + accessTokens, nil
+}
+
+// issueTokenPair builds a token pair for the session of a family
+// and records the refresh token in the family
+func (svc AuthService) issueTokenPair(ctx context.Context, reqTime time.Time, userId int, familyId string) (dto.TokenResponse, error) {
+	accessToken, accessJti, err := svc.buildJwt(reqTime, time.Duration(svc.jwtCfg.AccessTokenDuration), userId, familyId, jwtScopeAccess)
 	if err != nil {
 		return dto.TokenResponse{}, err
 	}
 
-	refreshToken, err := svc.buildJwt(reqTime, time.Duration(svc.jwtCfg.RefreshTokenDuration), userId, jwtScopeRefresh)
+	refreshToken, jti, err := svc.buildJwt(reqTime, time.Duration(svc.jwtCfg.RefreshTokenDuration), userId, familyId, jwtScopeRefresh)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	err = svc.refreshTokenRepo.Save(ctx, RefreshToken{
+		JTI:       jti,
+		FamilyID:  familyId,
+		AccountID: userId,
+		AccessJTI: &accessJti,
+		ExpiresAt: refreshToken.ExpiresAt,
+		CreatedAt: reqTime,
+	})
+
 	if err != nil {
 		return dto.TokenResponse{}, err
 	}
@@ -142,39 +372,97 @@ func (svc AuthService) buildTokenPair(reqTime time.Time, userId int) (dto.TokenR
 	}, nil
 }
 
-func (svc AuthService) validateToken(tokenStr string, scope string) (int, error) {
+// verificationKey returns the key of the token's kid. Tokens without
+// a kid were issued before key ids were added, by JWT_SIGNING_KEY.
+func (svc AuthService) verificationKey(t *jwt.Token) (any, error) {
+	kid, ok := t.Header["kid"].(string)
+	if !ok {
+		kid = HMACKeyID
+	}
+
+	key, ok := svc.keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	// A key only verifies its own algorithm, so a public key
+	// can never be used as an HMAC secret
+	if t.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("key %q does not use %s", key.ID, t.Method.Alg())
+	}
+
+	return key.VerificationKey(), nil
+}
+
+// GetJWKS returns the public keys tokens are verified with
+func (svc AuthService) GetJWKS() keyset.JWKS {
+	return svc.keys.JWKS()
+}
+
+func (svc AuthService) validateToken(tokenStr string, scope string) (int, jwtClaims, error) {
 	var claims jwtClaims
 
-	_, err := jwt.ParseWithClaims(tokenStr, &claims, func(t *jwt.Token) (any, error) {
-		return svc.jwtCfg.SigningKey.Decoded, nil
-	},
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(svc.keys.Algorithms()),
 		jwt.WithExpirationRequired(),
 		jwt.WithIssuedAt(),
-	)
+		jwt.WithIssuer(svc.jwtCfg.Issuer),
+		jwt.WithLeeway(time.Duration(svc.jwtCfg.Leeway)),
+	}
+
+	// An empty audience would require tokens to have an empty aud
+	if svc.jwtCfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(svc.jwtCfg.Audience))
+	}
 
+	_, err := jwt.ParseWithClaims(tokenStr, &claims, svc.verificationKey, opts...)
 	if err != nil {
 		switch {
+		// ErrTokenInvalidClaims wraps the exp, nbf, iat, iss and aud errors
 		case errors.Is(err, jwt.ErrTokenMalformed), errors.Is(err, jwt.ErrTokenUnverifiable),
-			errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenExpired),
-			errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
-			return 0, ErrInvalidToken
+			errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenInvalidClaims):
+		default:
+			// Tokens failing for any other reason are rejected all the same
+			svc.logger.Error(fmt.Sprintf("error on parsing token: %v", err))
 		}
+
+		return 0, jwtClaims{}, ErrInvalidToken
 	}
 
 	if claims.Scope != scope {
-		return 0, ErrInvalidToken
+		return 0, jwtClaims{}, ErrInvalidToken
 	}
 
 	userId, err := strconv.Atoi(claims.Subject)
 	if err != nil {
 		svc.logger.Error(fmt.Sprintf("error on parsing subject as user id: %v", err))
-		return 0, fmt.Errorf("parsing subject as user id error: %v", err)
+		return 0, jwtClaims{}, fmt.Errorf("parsing subject as user id error: %v", err)
 	}
 
-	return userId, nil
+	return userId, claims, nil
 }
 
-func (svc AuthService) ValidateAccessToken(ctx context.Context, tokenStr string) (int, error) {
-	return svc.validateToken(tokenStr, jwtScopeAccess)
+// ValidateAccessToken returns who the access token was issued to,
+// if the token is valid and has not been revoked
+func (svc AuthService) ValidateAccessToken(ctx context.Context, tokenStr string) (Identity, error) {
+	userId, claims, err := svc.validateToken(tokenStr, jwtScopeAccess)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	revoked, err := svc.revocations.IsRevoked(ctx, claims.ID, userId, issuedAt)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if revoked {
+		return Identity{}, ErrInvalidToken
+	}
+
+	return Identity{AccountID: userId, SessionID: claims.SessionID}, nil
 }
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/tamboto2000/otaqku-tasks/pkg/keyset"
+)
+
+func TestVerificationKey(t *testing.T) {
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	rsKey, err := keyset.ParsePEM("rs", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// The keys after switching from JWT_SIGNING_KEY to a key directory
+	keys, err := keyset.NewSet([]keyset.Key{rsKey, keyset.NewHMACVerificationKey(HMACKeyID, secret)}, "rs")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	svc := AuthService{keys: keys}
+
+	claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+
+	// Tokens issued before key ids were added have no kid
+	oldToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	newJwt := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	newJwt.Header["kid"] = "rs"
+	newToken, err := newJwt.SignedString(rsKey.SigningKey())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	forgedJwt := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forgedJwt.Header["kid"] = "unknown"
+	forgedToken, err := forgedJwt.SignedString(secret)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "HS256 token without kid", token: oldToken},
+		{name: "RS256 token of the active key", token: newToken},
+		{name: "unknown kid", token: forgedToken, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.token, svc.verificationKey, jwt.WithValidMethods(keys.Algorithms()))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
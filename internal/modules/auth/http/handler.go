@@ -1,6 +1,11 @@
 package http
 
 import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/tamboto2000/otaqku-tasks/internal/common"
 	"github.com/tamboto2000/otaqku-tasks/internal/dto"
@@ -8,11 +13,13 @@ import (
 )
 
 type AuthHandler struct {
-	authSvc auth.AuthService
+	authSvc  auth.AuthService
+	logger   *slog.Logger
+	authMddl echo.MiddlewareFunc
 }
 
-func NewAuthHandler(authSvc auth.AuthService) AuthHandler {
-	return AuthHandler{authSvc: authSvc}
+func NewAuthHandler(authSvc auth.AuthService, logger *slog.Logger, authMddl echo.MiddlewareFunc) AuthHandler {
+	return AuthHandler{authSvc: authSvc, logger: logger, authMddl: authMddl}
 }
 
 func RegisterAuthHandler(h AuthHandler, router *echo.Echo) {
@@ -20,6 +27,12 @@ func RegisterAuthHandler(h AuthHandler, router *echo.Echo) {
 	group.POST("/account", h.RegisterAccount)
 	group.POST("/login", h.Login)
 	group.POST("/refresh_token", h.ExchangeRefreshToken)
+	group.POST("/logout", h.Logout)
+	group.GET("/sessions", h.GetSessions, h.authMddl)
+	group.DELETE("/sessions", h.RevokeOtherSessions, h.authMddl)
+	group.DELETE("/sessions/:id", h.RevokeSession, h.authMddl)
+
+	router.GET("/.well-known/jwks.json", h.GetJWKS)
 }
 
 func (h AuthHandler) RegisterAccount(ectx echo.Context) error {
@@ -45,7 +58,12 @@ func (h AuthHandler) Login(ectx echo.Context) error {
 		return common.InvalidReqBodyResponse(ectx, err)
 	}
 
-	tokens, err := h.authSvc.Login(ctx, req.Email, req.Password)
+	meta := auth.SessionMetadata{
+		UserAgent: ectx.Request().UserAgent(),
+		IPAddress: ectx.RealIP(),
+	}
+
+	tokens, err := h.authSvc.Login(ctx, req.Email, req.Password, meta)
 	if err != nil {
 		return common.ErrorResponse(ectx, err)
 	}
@@ -61,10 +79,103 @@ func (h AuthHandler) ExchangeRefreshToken(ectx echo.Context) error {
 		return common.InvalidReqBodyResponse(ectx, err)
 	}
 
-	tokens, err := h.authSvc.ExchangeRefreshToken(ctx, req.RefreshToken)
+	meta := auth.SessionMetadata{
+		UserAgent: ectx.Request().UserAgent(),
+		IPAddress: ectx.RealIP(),
+	}
+
+	tokens, err := h.authSvc.ExchangeRefreshToken(ctx, req.RefreshToken, meta)
 	if err != nil {
 		return common.ErrorResponse(ectx, err)
 	}
 
 	return common.OKResponse(ectx, "success", tokens)
 }
+
+func (h AuthHandler) Logout(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.LogoutRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	if err := h.authSvc.Logout(ctx, req.RefreshToken, req.All); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
+
+func (h AuthHandler) GetSessions(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	identity, err := identityFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	sessions, err := h.authSvc.GetSessions(ctx, identity)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", sessions)
+}
+
+func (h AuthHandler) RevokeSession(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := uuid.Parse(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid session id"))
+	}
+
+	if err := h.authSvc.RevokeSession(ctx, accId, id.String()); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
+
+func (h AuthHandler) RevokeOtherSessions(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	identity, err := identityFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	if err := h.authSvc.RevokeOtherSessions(ctx, identity); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
+
+func identityFromEchoCtx(ectx echo.Context) (auth.Identity, error) {
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return auth.Identity{}, err
+	}
+
+	sessionId, err := common.SessionIDFromEchoCtx(ectx)
+	if err != nil {
+		return auth.Identity{}, err
+	}
+
+	return auth.Identity{AccountID: accId, SessionID: sessionId}, nil
+}
+
+// GetJWKS responds with a bare JSON Web Key Set, as expected by JWT libraries,
+// so other services can verify access tokens
+func (h AuthHandler) GetJWKS(ectx echo.Context) error {
+	ectx.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+
+	return ectx.JSON(http.StatusOK, h.authSvc.GetJWKS())
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/vinovest/sqlx"
+)
+
+// RefreshToken is an issued refresh token. A refresh token can only be
+// exchanged once, the token issued in exchange joins its family.
+type RefreshToken struct {
+	JTI       string `db:"jti"`
+	FamilyID  string `db:"family_id"`
+	AccountID int    `db:"account_id"`
+	// AccessJTI is the JTI of the access token issued along with the token
+	AccessJTI *string    `db:"access_jti"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, token RefreshToken) error
+	// GetByJTIForUpdate fetches a token and locks it
+	// until the end of the transaction
+	GetByJTIForUpdate(ctx context.Context, jti string) (RefreshToken, error)
+	// GetByFamilyIDCreatedAfter returns the tokens of a family
+	// created after the given time
+	GetByFamilyIDCreatedAfter(ctx context.Context, familyId string, createdAfter time.Time) ([]RefreshToken, error)
+	MarkUsedByJTI(ctx context.Context, jti string, usedAt time.Time) error
+	RevokeByFamilyID(ctx context.Context, familyId string, revokedAt time.Time) error
+	RevokeByAccountID(ctx context.Context, accId int, revokedAt time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type PostgreRefreshTokenRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreRefreshTokenRepository(db *sqlx.DB, logger *slog.Logger) PostgreRefreshTokenRepository {
+	return PostgreRefreshTokenRepository{db: db, logger: logger}
+}
+
+func (repo PostgreRefreshTokenRepository) Save(ctx context.Context, token RefreshToken) error {
+	q := `INSERT INTO refresh_tokens (jti, family_id, account_id, access_jti, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, token.JTI, token.FamilyID, token.AccountID, token.AccessJTI, token.ExpiresAt.UTC(), token.CreatedAt.UTC())
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving refresh token: %v", err), slog.Int("account_id", token.AccountID))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreRefreshTokenRepository) GetByJTIForUpdate(ctx context.Context, jti string) (RefreshToken, error) {
+	q := `SELECT jti, family_id, account_id, access_jti, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens WHERE jti = $1 FOR UPDATE`
+
+	var token RefreshToken
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &token, q, jti); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RefreshToken{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching refresh token: %v", err), slog.String("jti", jti))
+		return RefreshToken{}, err
+	}
+
+	return token, nil
+}
+
+func (repo PostgreRefreshTokenRepository) GetByFamilyIDCreatedAfter(ctx context.Context, familyId string, createdAfter time.Time) ([]RefreshToken, error) {
+	q := `SELECT jti, family_id, account_id, access_jti, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens WHERE family_id = $1 AND created_at > $2`
+
+	var tokens []RefreshToken
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &tokens, q, familyId, createdAfter.UTC()); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching refresh token family: %v", err), slog.String("family_id", familyId))
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (repo PostgreRefreshTokenRepository) MarkUsedByJTI(ctx context.Context, jti string, usedAt time.Time) error {
+	q := `UPDATE refresh_tokens SET used_at = $1 WHERE jti = $2 AND used_at IS NULL`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, usedAt.UTC(), jti)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on marking refresh token as used: %v", err), slog.String("jti", jti))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreRefreshTokenRepository) RevokeByFamilyID(ctx context.Context, familyId string, revokedAt time.Time) error {
+	q := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+
+	if _, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, revokedAt.UTC(), familyId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on revoking refresh token family: %v", err), slog.String("family_id", familyId))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreRefreshTokenRepository) RevokeByAccountID(ctx context.Context, accId int, revokedAt time.Time) error {
+	q := `UPDATE refresh_tokens SET revoked_at = $1 WHERE account_id = $2 AND revoked_at IS NULL`
+
+	if _, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, revokedAt.UTC(), accId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on revoking refresh tokens of an account: %v", err), slog.Int("account_id", accId))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreRefreshTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	q := `DELETE FROM refresh_tokens WHERE expires_at <= $1`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, now.UTC())
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting expired refresh tokens: %v", err))
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/pkg/ttlcache"
+	"github.com/vinovest/sqlx"
+)
+
+// syncOverlap is how far back a sync looks past the previous one,
+// to catch revocations of other instances with a lagging clock
+// or committed after the previous sync
+const syncOverlap = time.Minute
+
+// RevokedToken is a revoked access token, kept until the token expires
+type RevokedToken struct {
+	JTI       string    `db:"jti"`
+	AccountID int       `db:"account_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// AccountRevocation revokes the access tokens of an account
+// issued before RevokedBefore
+type AccountRevocation struct {
+	AccountID     int       `db:"account_id"`
+	RevokedBefore time.Time `db:"revoked_before"`
+}
+
+type RevocationRepository interface {
+	SaveRevokedToken(ctx context.Context, token RevokedToken, revokedAt time.Time) error
+	// SaveAccountRevocation keeps the latest RevokedBefore of the account
+	SaveAccountRevocation(ctx context.Context, rev AccountRevocation, revokedAt time.Time) error
+	// GetRevokedTokensSince returns the tokens revoked since the given time
+	// that have not expired yet
+	GetRevokedTokensSince(ctx context.Context, since, now time.Time) ([]RevokedToken, error)
+	// GetAccountRevocationsSince returns the account revocations made since the given
+	// time that can still match unexpired tokens, revoked after issuedAfter
+	GetAccountRevocationsSince(ctx context.Context, since, issuedAfter time.Time) ([]AccountRevocation, error)
+	DeleteExpiredTokens(ctx context.Context, now time.Time) (int, error)
+}
+
+type PostgreRevocationRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreRevocationRepository(db *sqlx.DB, logger *slog.Logger) PostgreRevocationRepository {
+	return PostgreRevocationRepository{db: db, logger: logger}
+}
+
+func (repo PostgreRevocationRepository) SaveRevokedToken(ctx context.Context, token RevokedToken, revokedAt time.Time) error {
+	q := `INSERT INTO revoked_access_tokens (jti, account_id, expires_at, revoked_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING`
+
+	_, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, token.JTI, token.AccountID, token.ExpiresAt.UTC(), revokedAt.UTC())
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving revoked token: %v", err), slog.Int("account_id", token.AccountID))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreRevocationRepository) SaveAccountRevocation(ctx context.Context, rev AccountRevocation, revokedAt time.Time) error {
+	q := `INSERT INTO account_token_revocations (account_id, revoked_before, revoked_at) VALUES ($1, $2, $3)
+		ON CONFLICT (account_id) DO UPDATE SET
+			revoked_before = GREATEST(account_token_revocations.revoked_before, EXCLUDED.revoked_before),
+			revoked_at = EXCLUDED.revoked_at`
+
+	_, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, rev.AccountID, rev.RevokedBefore.UTC(), revokedAt.UTC())
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving account token revocation: %v", err), slog.Int("account_id", rev.AccountID))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreRevocationRepository) GetRevokedTokensSince(ctx context.Context, since, now time.Time) ([]RevokedToken, error) {
+	q := `SELECT jti, account_id, expires_at FROM revoked_access_tokens WHERE revoked_at >= $1 AND expires_at > $2`
+
+	var tokens []RevokedToken
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &tokens, q, since.UTC(), now.UTC()); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching revoked tokens: %v", err))
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (repo PostgreRevocationRepository) GetAccountRevocationsSince(ctx context.Context, since, issuedAfter time.Time) ([]AccountRevocation, error) {
+	q := `SELECT account_id, revoked_before FROM account_token_revocations WHERE revoked_at >= $1 AND revoked_before > $2`
+
+	var revs []AccountRevocation
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &revs, q, since.UTC(), issuedAfter.UTC()); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching account token revocations: %v", err))
+		return nil, err
+	}
+
+	return revs, nil
+}
+
+func (repo PostgreRevocationRepository) DeleteExpiredTokens(ctx context.Context, now time.Time) (int, error) {
+	q := `DELETE FROM revoked_access_tokens WHERE expires_at <= $1`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, now.UTC())
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting expired revoked tokens: %v", err))
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// RevocationStore tells whether an access token has been revoked.
+// Revocations are stored in the repository and mirrored in memory, so checks
+// don't hit the database. The mirror is refreshed from the repository every
+// sync interval to pick up the revocations of other instances, and entries
+// are dropped once the tokens they match have expired, leeway included.
+type RevocationStore struct {
+	repo          RevocationRepository
+	tokenLifetime time.Duration
+	// leeway is how long after their expiry tokens are still accepted
+	leeway       time.Duration
+	syncInterval time.Duration
+
+	tokens   *ttlcache.Cache[string, struct{}]
+	accounts *ttlcache.Cache[int, time.Time]
+
+	// mu guards syncedAt, and is held during a sync
+	mu       sync.Mutex
+	syncedAt time.Time
+}
+
+// NewRevocationStore creates a store for access tokens that live for tokenLifetime
+// and are accepted for leeway past their expiry
+func NewRevocationStore(repo RevocationRepository, tokenLifetime, leeway, syncInterval time.Duration) *RevocationStore {
+	return &RevocationStore{
+		repo:          repo,
+		tokenLifetime: tokenLifetime,
+		leeway:        leeway,
+		syncInterval:  syncInterval,
+		tokens:        ttlcache.New[string, struct{}](),
+		accounts:      ttlcache.New[int, time.Time](),
+	}
+}
+
+// RevokeToken revokes a single access token
+func (s *RevocationStore) RevokeToken(ctx context.Context, jti string, accId int, expiresAt time.Time) error {
+	token := RevokedToken{JTI: jti, AccountID: accId, ExpiresAt: expiresAt}
+	if err := s.repo.SaveRevokedToken(ctx, token, time.Now()); err != nil {
+		return err
+	}
+
+	s.tokens.SetUntil(jti, struct{}{}, expiresAt.Add(s.leeway))
+
+	return nil
+}
+
+// RevokeIssuedBefore revokes the access tokens of an account issued before the given time.
+// Token issue times are in seconds, so the time is rounded up to the next second
+// to also revoke the tokens issued earlier in its second.
+func (s *RevocationStore) RevokeIssuedBefore(ctx context.Context, accId int, before time.Time) error {
+	rev := AccountRevocation{AccountID: accId, RevokedBefore: before.Truncate(time.Second).Add(time.Second)}
+	if err := s.repo.SaveAccountRevocation(ctx, rev, time.Now()); err != nil {
+		return err
+	}
+
+	s.setAccountRevocation(rev)
+
+	return nil
+}
+
+func (s *RevocationStore) setAccountRevocation(rev AccountRevocation) {
+	if current, ok := s.accounts.Get(rev.AccountID); ok && current.After(rev.RevokedBefore) {
+		return
+	}
+
+	s.accounts.SetUntil(rev.AccountID, rev.RevokedBefore, rev.RevokedBefore.Add(s.tokenLifetime+s.leeway))
+}
+
+// IsRevoked tells whether the access token with the JTI,
+// issued to the account at issuedAt, has been revoked
+func (s *RevocationStore) IsRevoked(ctx context.Context, jti string, accId int, issuedAt time.Time) (bool, error) {
+	if err := s.syncIfDue(ctx); err != nil {
+		return false, err
+	}
+
+	if _, ok := s.tokens.Get(jti); ok {
+		return true, nil
+	}
+
+	if before, ok := s.accounts.Get(accId); ok && issuedAt.Before(before) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (s *RevocationStore) syncIfDue(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if !s.syncedAt.IsZero() && now.Sub(s.syncedAt) < s.syncInterval {
+		return nil
+	}
+
+	// The first sync loads every revocation still in effect
+	var since time.Time
+	if !s.syncedAt.IsZero() {
+		since = s.syncedAt.Add(-syncOverlap)
+	}
+
+	// Tokens are still accepted for the leeway after they expire
+	tokens, err := s.repo.GetRevokedTokensSince(ctx, since, now.Add(-s.leeway))
+	if err != nil {
+		return err
+	}
+
+	revs, err := s.repo.GetAccountRevocationsSince(ctx, since, now.Add(-s.tokenLifetime-s.leeway))
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		s.tokens.SetUntil(token.JTI, struct{}{}, token.ExpiresAt.Add(s.leeway))
+	}
+
+	for _, rev := range revs {
+		s.setAccountRevocation(rev)
+	}
+
+	s.syncedAt = now
+
+	return nil
+}
+
+// PurgeExpired deletes the revocations of expired tokens
+func (s *RevocationStore) PurgeExpired(ctx context.Context) (int, error) {
+	s.tokens.DeleteExpired()
+	s.accounts.DeleteExpired()
+
+	return s.repo.DeleteExpiredTokens(ctx, time.Now().Add(-s.leeway))
+}
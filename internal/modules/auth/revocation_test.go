@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nopRevocationRepository stores nothing, leaving the store to its memory
+type nopRevocationRepository struct{}
+
+func (nopRevocationRepository) SaveRevokedToken(ctx context.Context, token RevokedToken, revokedAt time.Time) error {
+	return nil
+}
+
+func (nopRevocationRepository) SaveAccountRevocation(ctx context.Context, rev AccountRevocation, revokedAt time.Time) error {
+	return nil
+}
+
+func (nopRevocationRepository) GetRevokedTokensSince(ctx context.Context, since, now time.Time) ([]RevokedToken, error) {
+	return nil, nil
+}
+
+func (nopRevocationRepository) GetAccountRevocationsSince(ctx context.Context, since, issuedAfter time.Time) ([]AccountRevocation, error) {
+	return nil, nil
+}
+
+func (nopRevocationRepository) DeleteExpiredTokens(ctx context.Context, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestRevocationStore_RevokeIssuedBefore(t *testing.T) {
+	ctx := context.Background()
+	second := time.Now().Truncate(time.Second)
+	revokedAt := second.Add(500 * time.Millisecond)
+
+	store := NewRevocationStore(nopRevocationRepository{}, time.Hour, 0, time.Hour)
+	if err := store.RevokeIssuedBefore(ctx, 1, revokedAt); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		name     string
+		accId    int
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "Issued in an earlier second", accId: 1, issuedAt: second.Add(-time.Second), want: true},
+		// Issue times are in seconds, the token may be issued before revokedAt
+		{name: "Issued in the same second", accId: 1, issuedAt: second, want: true},
+		{name: "Issued in the next second", accId: 1, issuedAt: second.Add(time.Second)},
+		{name: "Other account", accId: 2, issuedAt: second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := store.IsRevoked(ctx, "jti", tt.accId, tt.issuedAt)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			assert.Equal(t, tt.want, revoked)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/vinovest/sqlx"
+)
+
+const maxUserAgentLength = 500
+
+// SessionMetadata describes the client a session was started from
+type SessionMetadata struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Session is a login of an account. Its ID is the family
+// of the refresh tokens rotated from the login.
+type Session struct {
+	ID         string     `db:"id"`
+	AccountID  int        `db:"account_id"`
+	UserAgent  string     `db:"user_agent"`
+	IPAddress  string     `db:"ip_address"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt time.Time  `db:"last_used_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+func newSession(id string, accId int, meta SessionMetadata, now, expiresAt time.Time) Session {
+	userAgent := meta.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+		for !utf8.ValidString(userAgent) {
+			userAgent = userAgent[:len(userAgent)-1]
+		}
+	}
+
+	// The address comes from a header when behind a proxy,
+	// anything that is not an IP address is not recorded
+	var ipAddress string
+	if addr, err := netip.ParseAddr(meta.IPAddress); err == nil {
+		ipAddress = addr.Unmap().WithZone("").String()
+	}
+
+	return Session{
+		ID:         id,
+		AccountID:  accId,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+	}
+}
+
+type SessionRepository interface {
+	Save(ctx context.Context, session Session) error
+	// GetActiveByAccountID returns the sessions of an account
+	// that are neither revoked nor expired, most recently used first
+	GetActiveByAccountID(ctx context.Context, accId int, now time.Time) ([]Session, error)
+	GetActiveByAccountIDAndID(ctx context.Context, accId int, id string, now time.Time) (Session, error)
+	// TouchByID records the use of a session, which is extended to expiresAt.
+	// common.ErrNotFound is returned if the session does not exist.
+	TouchByID(ctx context.Context, id string, usedAt, expiresAt time.Time) error
+	RevokeByID(ctx context.Context, id string, revokedAt time.Time) error
+	RevokeByAccountID(ctx context.Context, accId int, revokedAt time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type PostgreSessionRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreSessionRepository(db *sqlx.DB, logger *slog.Logger) PostgreSessionRepository {
+	return PostgreSessionRepository{db: db, logger: logger}
+}
+
+func (repo PostgreSessionRepository) Save(ctx context.Context, session Session) error {
+	q := `INSERT INTO auth_sessions (id, account_id, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, session.ID, session.AccountID, session.UserAgent, session.IPAddress,
+		session.CreatedAt.UTC(), session.LastUsedAt.UTC(), session.ExpiresAt.UTC())
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving session: %v", err), slog.Int("account_id", session.AccountID))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreSessionRepository) GetActiveByAccountID(ctx context.Context, accId int, now time.Time) ([]Session, error) {
+	q := `SELECT id, account_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM auth_sessions
+		WHERE account_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC, id`
+
+	var sessions []Session
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &sessions, q, accId, now.UTC()); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching sessions: %v", err), slog.Int("account_id", accId))
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (repo PostgreSessionRepository) GetActiveByAccountIDAndID(ctx context.Context, accId int, id string, now time.Time) (Session, error) {
+	q := `SELECT id, account_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM auth_sessions
+		WHERE account_id = $1 AND id = $2 AND revoked_at IS NULL AND expires_at > $3`
+
+	var session Session
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &session, q, accId, id, now.UTC()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Session{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a session: %v", err), slog.Int("account_id", accId), slog.String("id", id))
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+func (repo PostgreSessionRepository) TouchByID(ctx context.Context, id string, usedAt, expiresAt time.Time) error {
+	q := `UPDATE auth_sessions SET last_used_at = $1, expires_at = $2 WHERE id = $3`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, usedAt.UTC(), expiresAt.UTC(), id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating session usage: %v", err), slog.String("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreSessionRepository) RevokeByID(ctx context.Context, id string, revokedAt time.Time) error {
+	q := `UPDATE auth_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+
+	if _, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, revokedAt.UTC(), id); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on revoking a session: %v", err), slog.String("id", id))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreSessionRepository) RevokeByAccountID(ctx context.Context, accId int, revokedAt time.Time) error {
+	q := `UPDATE auth_sessions SET revoked_at = $1 WHERE account_id = $2 AND revoked_at IS NULL`
+
+	if _, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, revokedAt.UTC(), accId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on revoking sessions of an account: %v", err), slog.Int("account_id", accId))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreSessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	q := `DELETE FROM auth_sessions WHERE expires_at <= $1`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, now.UTC())
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting expired sessions: %v", err))
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
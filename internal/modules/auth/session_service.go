@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+// GetSessions returns the active sessions of the account,
+// marking the one the identity is signed in with
+func (svc AuthService) GetSessions(ctx context.Context, identity Identity) ([]dto.Session, error) {
+	sessions, err := svc.sessionRepo.GetActiveByAccountID(ctx, identity.AccountID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	sessionDtos := []dto.Session{}
+	for _, session := range sessions {
+		sessionDtos = append(sessionDtos, sessionToSessionDTO(session, identity.SessionID))
+	}
+
+	return sessionDtos, nil
+}
+
+// RevokeSession signs a session of the account out
+func (svc AuthService) RevokeSession(ctx context.Context, accId int, id string) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		if _, err := svc.sessionRepo.GetActiveByAccountIDAndID(ctx, accId, id, now); err != nil {
+			return err
+		}
+
+		return svc.revokeFamily(ctx, id, now)
+	})
+}
+
+// RevokeOtherSessions signs every session of the account out,
+// except the one the identity is signed in with
+func (svc AuthService) RevokeOtherSessions(ctx context.Context, identity Identity) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		sessions, err := svc.sessionRepo.GetActiveByAccountID(ctx, identity.AccountID, now)
+		if err != nil {
+			return err
+		}
+
+		for _, session := range sessions {
+			if session.ID == identity.SessionID {
+				continue
+			}
+
+			if err := svc.revokeFamily(ctx, session.ID, now); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func sessionToSessionDTO(session Session, currentId string) dto.Session {
+	return dto.Session{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		Current:    session.ID == currentId,
+	}
+}
@@ -0,0 +1,213 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/internal/modules/project"
+)
+
+type ProjectHandler struct {
+	projectSvc project.ProjectService
+	logger     *slog.Logger
+	authMddl   echo.MiddlewareFunc
+}
+
+func NewProjectHandler(projectSvc project.ProjectService, logger *slog.Logger, authMddl echo.MiddlewareFunc) ProjectHandler {
+	return ProjectHandler{projectSvc: projectSvc, logger: logger, authMddl: authMddl}
+}
+
+func RegisterProjectHandler(h ProjectHandler, router *echo.Echo) {
+	group := router.Group("projects", h.authMddl)
+	group.POST("", h.CreateProject)
+	group.GET("", h.GetProjectList)
+	group.GET("/:id", h.GetByID)
+	group.PUT("/:id", h.Update)
+	group.DELETE("/:id", h.Delete)
+	group.POST("/:id/archive", h.Archive)
+	group.POST("/:id/unarchive", h.Unarchive)
+	group.GET("/:id/tasks", h.GetTasks)
+}
+
+func (h ProjectHandler) CreateProject(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.CreateProjectRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	project, err := h.projectSvc.CreateProject(ctx, accId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", project)
+}
+
+func (h ProjectHandler) GetProjectList(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Pagination
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	var filter dto.ProjectFilter
+	if err := ectx.Bind(&filter); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	projectList, err := h.projectSvc.GetProjectList(ctx, accId, filter, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", projectList)
+}
+
+func (h ProjectHandler) GetByID(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := strconv.Atoi(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid project number"))
+	}
+
+	project, err := h.projectSvc.GetByID(ctx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", project)
+}
+
+func (h ProjectHandler) Update(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Project
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := strconv.Atoi(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid project number"))
+	}
+
+	req.ID = id
+
+	project, err := h.projectSvc.Update(ctx, accId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", project)
+}
+
+// Delete deletes a project. The tasks query parameter decides what happens
+// to its tasks: "inbox" (default) keeps them without a project,
+// "cascade" moves them to the trash.
+func (h ProjectHandler) Delete(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := strconv.Atoi(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid project number"))
+	}
+
+	if err := h.projectSvc.Delete(ctx, accId, id, ectx.QueryParam("tasks")); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
+
+func (h ProjectHandler) Archive(ectx echo.Context) error {
+	return h.setArchived(ectx, true)
+}
+
+func (h ProjectHandler) Unarchive(ectx echo.Context) error {
+	return h.setArchived(ectx, false)
+}
+
+func (h ProjectHandler) setArchived(ectx echo.Context, archived bool) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := strconv.Atoi(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid project number"))
+	}
+
+	project, err := h.projectSvc.SetArchived(ctx, accId, id, archived)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", project)
+}
+
+func (h ProjectHandler) GetTasks(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Pagination
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	var filter dto.TaskFilter
+	if err := ectx.Bind(&filter); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := strconv.Atoi(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid project number"))
+	}
+
+	taskList, err := h.projectSvc.GetTasks(ctx, accId, id, filter, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", taskList)
+}
@@ -0,0 +1,206 @@
+package project
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/vinovest/sqlx"
+)
+
+type Project struct {
+	ID          int        `db:"id"`
+	AccountID   int        `db:"account_id"`
+	Name        string     `db:"name"`
+	Description string     `db:"description"`
+	ArchivedAt  *time.Time `db:"archived_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+}
+
+func (p Project) IsArchived() bool {
+	return p.ArchivedAt != nil
+}
+
+func NewProject(accId int, req dto.CreateProjectRequest) (Project, error) {
+	var errName common.FieldError
+	if len(req.Name) == 0 {
+		errName.Messages = append(errName.Messages, "name can not be empty")
+	}
+
+	if len(req.Name) > 100 {
+		errName.Messages = append(errName.Messages, "name can not be longer than 100 characters")
+	}
+
+	if len(errName.Messages) != 0 {
+		errName.Name = "name"
+		return Project{}, common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  []common.FieldError{errName},
+		}
+	}
+
+	return Project{
+		AccountID:   accId,
+		Name:        req.Name,
+		Description: req.Description,
+	}, nil
+}
+
+func ValidateProjectForUpdate(accId int, req dto.Project) (Project, error) {
+	project, err := NewProject(accId, dto.CreateProjectRequest{Name: req.Name, Description: req.Description})
+	if err != nil {
+		return Project{}, err
+	}
+
+	project.ID = req.ID
+
+	return project, nil
+}
+
+type ProjectList struct {
+	Projects   []Project
+	Pagination dto.PaginationMetadata
+}
+
+type ProjectRepository interface {
+	Save(ctx context.Context, project Project) (int, error)
+	GetByAccountID(ctx context.Context, accId int, archived bool, paginate dto.Pagination) (ProjectList, error)
+	GetByAccountIDAndID(ctx context.Context, accId, id int) (Project, error)
+	UpdateByAccountIDAndID(ctx context.Context, project Project) error
+	UpdateArchivedByAccountIDAndID(ctx context.Context, accId, id int, archived bool) error
+	DeleteByAccountIDAndID(ctx context.Context, accId, id int) error
+	// IsActiveByAccountIDAndID reports whether the project exists and is not archived
+	IsActiveByAccountIDAndID(ctx context.Context, accId, id int) (bool, error)
+}
+
+type PostgreProjectRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreProjectRepository(db *sqlx.DB, logger *slog.Logger) PostgreProjectRepository {
+	return PostgreProjectRepository{db: db, logger: logger}
+}
+
+func (repo PostgreProjectRepository) Save(ctx context.Context, project Project) (int, error) {
+	q := `INSERT INTO projects (account_id, name, description) VALUES ($1, $2, $3) RETURNING id`
+
+	var id int
+	row := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, project.AccountID, project.Name, project.Description)
+	if err := row.Scan(&id); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving project to database: %v", err), slog.Any("project", project))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreProjectRepository) GetByAccountID(ctx context.Context, accId int, archived bool, paginate dto.Pagination) (ProjectList, error) {
+	conn := database.Conn(ctx, repo.db)
+
+	where := `account_id = $1 AND archived_at IS NULL`
+	if archived {
+		where = `account_id = $1 AND archived_at IS NOT NULL`
+	}
+
+	q := `SELECT id, account_id, name, description, archived_at, created_at, updated_at
+		FROM projects WHERE ` + where + `
+		ORDER BY name, id
+		LIMIT $2 OFFSET $3`
+
+	var projects []Project
+	if err := conn.SelectContext(ctx, &projects, q, accId, paginate.PageSize, common.GetOffset(paginate.Page, paginate.PageSize)); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching list of projects: %v", err), slog.Int("account_id", accId))
+		return ProjectList{}, err
+	}
+
+	q = `SELECT COUNT(id) FROM projects WHERE ` + where
+	var totalCount int
+	if err := conn.QueryRowContext(ctx, q, accId).Scan(&totalCount); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching account's project count: %v", err), slog.Int("account_id", accId))
+		return ProjectList{}, err
+	}
+
+	return ProjectList{
+		Projects: projects,
+		Pagination: dto.PaginationMetadata{
+			Pagination: paginate,
+			Total:      totalCount,
+		},
+	}, nil
+}
+
+func (repo PostgreProjectRepository) GetByAccountIDAndID(ctx context.Context, accId, id int) (Project, error) {
+	q := `SELECT id, account_id, name, description, archived_at, created_at, updated_at
+		FROM projects WHERE account_id = $1 AND id = $2`
+
+	var project Project
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &project, q, accId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Project{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a single project: %v", err), slog.Int("account_id", accId), slog.Int("id", id))
+		return Project{}, err
+	}
+
+	return project, nil
+}
+
+func (repo PostgreProjectRepository) UpdateByAccountIDAndID(ctx context.Context, project Project) error {
+	q := `UPDATE projects SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP WHERE account_id = $3 AND id = $4`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, project.Name, project.Description, project.AccountID, project.ID)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating a project: %v", err), slog.Int("id", project.ID))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreProjectRepository) UpdateArchivedByAccountIDAndID(ctx context.Context, accId, id int, archived bool) error {
+	q := `UPDATE projects
+		SET archived_at = CASE WHEN $1 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $2 AND id = $3`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, archived, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on archiving a project: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreProjectRepository) DeleteByAccountIDAndID(ctx context.Context, accId, id int) error {
+	q := `DELETE FROM projects WHERE account_id = $1 AND id = $2`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting a project: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreProjectRepository) IsActiveByAccountIDAndID(ctx context.Context, accId, id int) (bool, error) {
+	q := `SELECT EXISTS (SELECT 1 FROM projects WHERE account_id = $1 AND id = $2 AND archived_at IS NULL)`
+
+	var active bool
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, accId, id).Scan(&active); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on checking if a project is active: %v", err), slog.Int("id", id))
+		return false, err
+	}
+
+	return active, nil
+}
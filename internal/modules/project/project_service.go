@@ -0,0 +1,156 @@
+package project
+
+import (
+	"context"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/internal/modules/task"
+)
+
+// What happens to the tasks of a deleted project
+const (
+	// DeleteModeInbox moves the tasks to the inbox
+	DeleteModeInbox = "inbox"
+	// DeleteModeCascade moves the tasks to the trash
+	DeleteModeCascade = "cascade"
+)
+
+var ErrInvalidDeleteMode = common.Error{
+	Code:    common.ErrCodeInputValidation,
+	Message: "Invalid input",
+	Fields: []common.FieldError{
+		{Name: "tasks", Messages: []string{"invalid delete mode, valid modes are: inbox and cascade"}},
+	},
+}
+
+type ProjectService struct {
+	projectRepo ProjectRepository
+	taskSvc     task.TaskService
+	transactor  database.Transactor
+}
+
+func NewProjectService(projectRepo ProjectRepository, taskSvc task.TaskService, transactor database.Transactor) ProjectService {
+	return ProjectService{
+		projectRepo: projectRepo,
+		taskSvc:     taskSvc,
+		transactor:  transactor,
+	}
+}
+
+func (svc ProjectService) CreateProject(ctx context.Context, accId int, req dto.CreateProjectRequest) (dto.Project, error) {
+	project, err := NewProject(accId, req)
+	if err != nil {
+		return dto.Project{}, err
+	}
+
+	id, err := svc.projectRepo.Save(ctx, project)
+	if err != nil {
+		return dto.Project{}, err
+	}
+
+	return svc.GetByID(ctx, accId, id)
+}
+
+func (svc ProjectService) GetProjectList(ctx context.Context, accId int, filter dto.ProjectFilter, paginate dto.Pagination) (dto.ProjectList, error) {
+	projectList, err := svc.projectRepo.GetByAccountID(ctx, accId, filter.Archived, paginate)
+	if err != nil {
+		return dto.ProjectList{}, err
+	}
+
+	projectListDto := dto.ProjectList{
+		Projects:   []dto.Project{},
+		Pagination: projectList.Pagination,
+	}
+
+	for _, project := range projectList.Projects {
+		projectListDto.Projects = append(projectListDto.Projects, projectToProjectDTO(project))
+	}
+
+	return projectListDto, nil
+}
+
+func (svc ProjectService) GetByID(ctx context.Context, accId, id int) (dto.Project, error) {
+	project, err := svc.projectRepo.GetByAccountIDAndID(ctx, accId, id)
+	if err != nil {
+		return dto.Project{}, err
+	}
+
+	return projectToProjectDTO(project), nil
+}
+
+func (svc ProjectService) Update(ctx context.Context, accId int, req dto.Project) (dto.Project, error) {
+	project, err := ValidateProjectForUpdate(accId, req)
+	if err != nil {
+		return dto.Project{}, err
+	}
+
+	if err := svc.projectRepo.UpdateByAccountIDAndID(ctx, project); err != nil {
+		return dto.Project{}, err
+	}
+
+	return svc.GetByID(ctx, accId, project.ID)
+}
+
+// SetArchived archives or unarchives a project. The tasks of an archived
+// project are kept, but no task can be added to it.
+func (svc ProjectService) SetArchived(ctx context.Context, accId, id int, archived bool) (dto.Project, error) {
+	if err := svc.projectRepo.UpdateArchivedByAccountIDAndID(ctx, accId, id, archived); err != nil {
+		return dto.Project{}, err
+	}
+
+	return svc.GetByID(ctx, accId, id)
+}
+
+// Delete deletes a project, the mode decides what happens to its tasks
+func (svc ProjectService) Delete(ctx context.Context, accId, id int, mode string) error {
+	if mode == "" {
+		mode = DeleteModeInbox
+	}
+
+	if mode != DeleteModeInbox && mode != DeleteModeCascade {
+		return ErrInvalidDeleteMode
+	}
+
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.projectRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		var err error
+		if mode == DeleteModeCascade {
+			err = svc.taskSvc.DeleteByProject(ctx, accId, id)
+		} else {
+			err = svc.taskSvc.MoveProjectTasksToInbox(ctx, accId, id)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return svc.projectRepo.DeleteByAccountIDAndID(ctx, accId, id)
+	})
+}
+
+func (svc ProjectService) GetTasks(ctx context.Context, accId, id int, filter dto.TaskFilter, paginate dto.Pagination) (dto.TaskList, error) {
+	if _, err := svc.projectRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+		return dto.TaskList{}, err
+	}
+
+	filter.ProjectID = &id
+
+	return svc.taskSvc.GetTaskList(ctx, accId, filter, paginate)
+}
+
+func projectToProjectDTO(project Project) dto.Project {
+	return dto.Project{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		Archived:    project.IsArchived(),
+		ArchivedAt:  project.ArchivedAt,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+	}
+}
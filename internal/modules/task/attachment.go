@@ -0,0 +1,179 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/vinovest/sqlx"
+)
+
+// Attachment is a file attached to a task. Its content is stored
+// in the blob storage under a key derived from SHA256.
+type Attachment struct {
+	ID          int       `db:"id"`
+	TaskID      int       `db:"task_id"`
+	AccountID   int       `db:"account_id"`
+	UploaderID  int       `db:"uploader_id"`
+	Filename    string    `db:"filename"`
+	ContentType string    `db:"content_type"`
+	Size        int64     `db:"size"`
+	SHA256      string    `db:"sha256"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// blobKey is the key of the attachment content in the blob storage.
+// Attachments with the same content share the blob.
+func (attachment Attachment) blobKey() string {
+	return contentBlobKey(attachment.SHA256)
+}
+
+func contentBlobKey(hash string) string {
+	return "sha256/" + hash[:2] + "/" + hash
+}
+
+type AttachmentRepository interface {
+	Save(ctx context.Context, attachment Attachment) (int, error)
+	GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int) ([]Attachment, error)
+	GetByAccountIDAndID(ctx context.Context, accId, taskId, id int) (Attachment, error)
+	DeleteByAccountIDAndID(ctx context.Context, accId, taskId, id int) error
+	// GetHashesByAccountIDAndTaskID returns the content hashes of a task's attachments,
+	// the task is locked so no attachment is added until the end of the transaction
+	GetHashesByAccountIDAndTaskID(ctx context.Context, accId, taskId int) ([]string, error)
+	// GetHashesOfTasksDeletedBefore is GetHashesByAccountIDAndTaskID
+	// for all the tasks moved to the trash before a time
+	GetHashesOfTasksDeletedBefore(ctx context.Context, before time.Time) ([]string, error)
+	// IsHashReferenced tells whether any attachment has the content hash
+	IsHashReferenced(ctx context.Context, hash string) (bool, error)
+	// LockHash serializes the transactions that store or delete the blob
+	// of a content hash, until the end of the current transaction
+	LockHash(ctx context.Context, hash string) error
+}
+
+type PostgreAttachmentRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreAttachmentRepository(db *sqlx.DB, logger *slog.Logger) PostgreAttachmentRepository {
+	return PostgreAttachmentRepository{db: db, logger: logger}
+}
+
+func (repo PostgreAttachmentRepository) Save(ctx context.Context, attachment Attachment) (int, error) {
+	q := `INSERT INTO task_attachments (task_id, account_id, uploader_id, filename, content_type, size, sha256)
+		VALUES (:task_id, :account_id, :uploader_id, :filename, :content_type, :size, :sha256)
+		RETURNING id`
+
+	conn := database.Conn(ctx, repo.db)
+	q, args, err := conn.BindNamed(q, attachment)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on binding task attachment insert query: %v", err))
+		return 0, err
+	}
+
+	var id int
+	if err := conn.QueryRowxContext(ctx, q, args...).Scan(&id); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving task attachment to database: %v", err), slog.Int("task_id", attachment.TaskID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreAttachmentRepository) GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int) ([]Attachment, error) {
+	q := `SELECT id, task_id, account_id, uploader_id, filename, content_type, size, sha256, created_at
+		FROM task_attachments
+		WHERE account_id = $1 AND task_id = $2
+		ORDER BY created_at, id`
+
+	var attachments []Attachment
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &attachments, q, accId, taskId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task attachments: %v", err), slog.Int("task_id", taskId))
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (repo PostgreAttachmentRepository) GetByAccountIDAndID(ctx context.Context, accId, taskId, id int) (Attachment, error) {
+	q := `SELECT id, task_id, account_id, uploader_id, filename, content_type, size, sha256, created_at
+		FROM task_attachments WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	var attachment Attachment
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &attachment, q, accId, taskId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Attachment{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a task attachment: %v", err), slog.Int("task_id", taskId), slog.Int("id", id))
+		return Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (repo PostgreAttachmentRepository) DeleteByAccountIDAndID(ctx context.Context, accId, taskId, id int) error {
+	q := `DELETE FROM task_attachments WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, taskId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting a task attachment: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreAttachmentRepository) GetHashesByAccountIDAndTaskID(ctx context.Context, accId, taskId int) ([]string, error) {
+	q := `SELECT DISTINCT sha256 FROM task_attachments
+		WHERE task_id IN (SELECT id FROM tasks WHERE account_id = $1 AND id = $2 FOR UPDATE)`
+
+	var hashes []string
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &hashes, q, accId, taskId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task attachment hashes: %v", err), slog.Int("task_id", taskId))
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+func (repo PostgreAttachmentRepository) GetHashesOfTasksDeletedBefore(ctx context.Context, before time.Time) ([]string, error) {
+	q := `SELECT DISTINCT sha256 FROM task_attachments
+		WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < $1 FOR UPDATE)`
+
+	var hashes []string
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &hashes, q, before.UTC()); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching attachment hashes of deleted tasks: %v", err))
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+func (repo PostgreAttachmentRepository) IsHashReferenced(ctx context.Context, hash string) (bool, error) {
+	q := `SELECT EXISTS (SELECT 1 FROM task_attachments WHERE sha256 = $1)`
+
+	var exists bool
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, hash).Scan(&exists); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on checking attachment content hash: %v", err))
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (repo PostgreAttachmentRepository) LockHash(ctx context.Context, hash string) error {
+	q := `SELECT pg_advisory_xact_lock(hashtext($1))`
+
+	if _, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, hash); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on locking attachment content hash: %v", err))
+		return err
+	}
+
+	return nil
+}
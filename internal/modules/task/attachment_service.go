@@ -0,0 +1,407 @@
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/pkg/storage"
+)
+
+const maxFilenameLength = 255
+
+var ErrAttachmentTooLarge = common.Error{
+	Code:    common.ErrCodePayloadTooLarge,
+	Message: "Attachment is too large",
+}
+
+// AttachmentUpload is an uploaded file. Content is read twice,
+// once to hash it and once to store it.
+type AttachmentUpload struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	Content     io.ReadSeeker
+}
+
+type AttachmentLimits struct {
+	// MaxSize is the maximum size of an attachment in bytes
+	MaxSize int64
+	// AllowedTypes are the accepted media types, type/* accepts all subtypes
+	AllowedTypes []string
+}
+
+func (limits AttachmentLimits) allows(mediaType string) bool {
+	for _, allowed := range limits.AllowedTypes {
+		if allowed == mediaType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContentCollector deletes the attachment contents no attachment refers to anymore.
+// Contents are only collected after the transaction removing the attachments
+// has committed, so a rolled back removal never loses a content.
+type ContentCollector struct {
+	attachmentRepo AttachmentRepository
+	blobs          storage.BlobStore
+	transactor     database.Transactor
+	logger         *slog.Logger
+}
+
+func NewContentCollector(
+	attachmentRepo AttachmentRepository,
+	blobs storage.BlobStore,
+	transactor database.Transactor,
+	logger *slog.Logger,
+) ContentCollector {
+	return ContentCollector{
+		attachmentRepo: attachmentRepo,
+		blobs:          blobs,
+		transactor:     transactor,
+		logger:         logger,
+	}
+}
+
+// collectAfterCommit collects the contents of hashes once
+// the transaction carried by ctx has committed
+func (c ContentCollector) collectAfterCommit(ctx context.Context, hashes []string) {
+	if len(hashes) == 0 {
+		return
+	}
+
+	database.AfterCommit(ctx, func(ctx context.Context) {
+		c.collect(ctx, hashes)
+	})
+}
+
+// collectTaskAfterCommit collects the attachment contents of a task
+// purged in the transaction carried by ctx, it must run before the purge
+func (c ContentCollector) collectTaskAfterCommit(ctx context.Context, accId, taskId int) error {
+	hashes, err := c.attachmentRepo.GetHashesByAccountIDAndTaskID(ctx, accId, taskId)
+	if err != nil {
+		return err
+	}
+
+	c.collectAfterCommit(ctx, hashes)
+
+	return nil
+}
+
+// collectTasksDeletedBeforeAfterCommit is collectTaskAfterCommit
+// for the tasks moved to the trash before a time
+func (c ContentCollector) collectTasksDeletedBeforeAfterCommit(ctx context.Context, before time.Time) error {
+	hashes, err := c.attachmentRepo.GetHashesOfTasksDeletedBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	c.collectAfterCommit(ctx, hashes)
+
+	return nil
+}
+
+// collect deletes the contents of hashes that are not referenced. A content
+// that fails to be deleted is only logged, it is left behind in the storage.
+func (c ContentCollector) collect(ctx context.Context, hashes []string) {
+	for _, hash := range hashes {
+		err := c.transactor.WithinTx(ctx, func(ctx context.Context) error {
+			// Serialized with the uploads storing the same content
+			if err := c.attachmentRepo.LockHash(ctx, hash); err != nil {
+				return err
+			}
+
+			referenced, err := c.attachmentRepo.IsHashReferenced(ctx, hash)
+			if err != nil || referenced {
+				return err
+			}
+
+			return c.blobs.Delete(ctx, contentBlobKey(hash))
+		})
+
+		if err != nil {
+			c.logger.Error(fmt.Sprintf("error on deleting attachment content: %v", err), slog.String("sha256", hash))
+		}
+	}
+}
+
+type AttachmentService struct {
+	attachmentRepo AttachmentRepository
+	taskRepo       TaskRepository
+	blobs          storage.BlobStore
+	contents       ContentCollector
+	transactor     database.Transactor
+	limits         AttachmentLimits
+}
+
+func NewAttachmentService(
+	attachmentRepo AttachmentRepository,
+	taskRepo TaskRepository,
+	blobs storage.BlobStore,
+	contents ContentCollector,
+	transactor database.Transactor,
+	limits AttachmentLimits,
+) AttachmentService {
+	return AttachmentService{
+		attachmentRepo: attachmentRepo,
+		taskRepo:       taskRepo,
+		blobs:          blobs,
+		contents:       contents,
+		transactor:     transactor,
+		limits:         limits,
+	}
+}
+
+// sanitizeFilename keeps the base name of a client given file name,
+// without the characters that could break a Content-Disposition header
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+
+		return r
+	}, name)
+
+	if len(name) > maxFilenameLength {
+		ext := filepath.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+
+		name = strings.ToValidUTF8(name[:maxFilenameLength-len(ext)], "") + ext
+	}
+
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+
+	return name
+}
+
+// detectContentType returns the media type declared by the client,
+// or the one sniffed from the content if none was declared
+func detectContentType(upload AttachmentUpload) (string, error) {
+	if mediaType, _, err := mime.ParseMediaType(upload.ContentType); err == nil && mediaType != "application/octet-stream" {
+		return mediaType, nil
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	if _, err := upload.Content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil {
+		return "application/octet-stream", nil
+	}
+
+	return mediaType, nil
+}
+
+func hashContent(content io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Upload attaches a file to a task. The content is stored only once
+// for all the attachments that have the same content.
+func (svc AttachmentService) Upload(ctx context.Context, accId, taskId int, upload AttachmentUpload) (dto.Attachment, error) {
+	if upload.Size > svc.limits.MaxSize {
+		errTooLarge := ErrAttachmentTooLarge
+		errTooLarge.Details = fmt.Sprintf("attachments can not be larger than %d bytes", svc.limits.MaxSize)
+
+		return dto.Attachment{}, errTooLarge
+	}
+
+	if upload.Size == 0 {
+		return dto.Attachment{}, common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  []common.FieldError{{Name: "file", Messages: []string{"file can not be empty"}}},
+		}
+	}
+
+	contentType, err := detectContentType(upload)
+	if err != nil {
+		return dto.Attachment{}, err
+	}
+
+	if !svc.limits.allows(contentType) {
+		return dto.Attachment{}, common.Error{
+			Code:    common.ErrCodeUnsupportedMediaType,
+			Message: "Attachment type is not allowed",
+			Details: fmt.Sprintf("allowed types are: %s", strings.Join(svc.limits.AllowedTypes, ", ")),
+		}
+	}
+
+	hash, err := hashContent(upload.Content)
+	if err != nil {
+		return dto.Attachment{}, err
+	}
+
+	attachment := Attachment{
+		TaskID:      taskId,
+		AccountID:   accId,
+		UploaderID:  accId,
+		Filename:    sanitizeFilename(upload.Filename),
+		ContentType: contentType,
+		Size:        upload.Size,
+		SHA256:      hash,
+	}
+
+	var created Attachment
+	var stored bool
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, taskId); err != nil {
+			return err
+		}
+
+		if err := svc.attachmentRepo.LockHash(ctx, hash); err != nil {
+			return err
+		}
+
+		referenced, err := svc.attachmentRepo.IsHashReferenced(ctx, hash)
+		if err != nil {
+			return err
+		}
+
+		if !referenced {
+			if err := svc.blobs.Put(ctx, attachment.blobKey(), upload.Content, upload.Size); err != nil {
+				return err
+			}
+
+			stored = true
+		}
+
+		id, err := svc.attachmentRepo.Save(ctx, attachment)
+		if err != nil {
+			return err
+		}
+
+		created, err = svc.attachmentRepo.GetByAccountIDAndID(ctx, accId, taskId, id)
+
+		return err
+	})
+
+	if err != nil {
+		// The content stored by the rolled back upload is not referenced
+		if stored {
+			svc.contents.collect(ctx, []string{hash})
+		}
+
+		return dto.Attachment{}, err
+	}
+
+	return attachmentToAttachmentDTO(created), nil
+}
+
+func (svc AttachmentService) GetAttachments(ctx context.Context, accId, taskId int) ([]dto.Attachment, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, taskId); err != nil {
+		return nil, err
+	}
+
+	attachments, err := svc.attachmentRepo.GetByAccountIDAndTaskID(ctx, accId, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	attachmentDtos := []dto.Attachment{}
+	for _, attachment := range attachments {
+		attachmentDtos = append(attachmentDtos, attachmentToAttachmentDTO(attachment))
+	}
+
+	return attachmentDtos, nil
+}
+
+// Open opens the content of an attachment, which must be closed by the caller
+func (svc AttachmentService) Open(ctx context.Context, accId, taskId, id int) (dto.Attachment, storage.Blob, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, taskId); err != nil {
+		return dto.Attachment{}, nil, err
+	}
+
+	attachment, err := svc.attachmentRepo.GetByAccountIDAndID(ctx, accId, taskId, id)
+	if err != nil {
+		return dto.Attachment{}, nil, err
+	}
+
+	blob, err := svc.blobs.Open(ctx, attachment.blobKey())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return dto.Attachment{}, nil, common.ErrNotFound
+		}
+
+		return dto.Attachment{}, nil, err
+	}
+
+	return attachmentToAttachmentDTO(attachment), blob, nil
+}
+
+// Delete removes an attachment, its content is deleted
+// once no other attachment has the same content
+func (svc AttachmentService) Delete(ctx context.Context, accId, taskId, id int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, taskId); err != nil {
+			return err
+		}
+
+		attachment, err := svc.attachmentRepo.GetByAccountIDAndID(ctx, accId, taskId, id)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.attachmentRepo.DeleteByAccountIDAndID(ctx, accId, taskId, id); err != nil {
+			return err
+		}
+
+		svc.contents.collectAfterCommit(ctx, []string{attachment.SHA256})
+
+		return nil
+	})
+}
+
+func attachmentToAttachmentDTO(attachment Attachment) dto.Attachment {
+	return dto.Attachment{
+		ID:          attachment.ID,
+		TaskID:      attachment.TaskID,
+		UploaderID:  attachment.UploaderID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		SHA256:      attachment.SHA256,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
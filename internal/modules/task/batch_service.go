@@ -0,0 +1,203 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+// Batch modes
+const (
+	// BatchModeAtomic applies all operations in a single transaction,
+	// nothing is applied if any of them fails
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort applies every operation on its own,
+	// a failing operation does not affect the others
+	BatchModeBestEffort = "best_effort"
+)
+
+// Batch operations
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// Batch operation result statuses
+const (
+	BatchStatusOK         = "ok"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+	BatchStatusSkipped    = "skipped"
+)
+
+const maxBatchOperations = 100
+
+var errBatchAborted = errors.New("batch aborted")
+
+func validateBatchRequest(req dto.BatchTaskRequest) (string, error) {
+	errValidation := common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid input",
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = BatchModeAtomic
+	}
+
+	if mode != BatchModeAtomic && mode != BatchModeBestEffort {
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "mode",
+			Messages: []string{"invalid mode, valid modes are: atomic and best_effort"},
+		})
+	}
+
+	errOps := common.FieldError{Name: "operations"}
+	if len(req.Operations) == 0 {
+		errOps.Messages = append(errOps.Messages, "operations can not be empty")
+	}
+
+	if len(req.Operations) > maxBatchOperations {
+		errOps.Messages = append(errOps.Messages, fmt.Sprintf("operations can not be more than %d", maxBatchOperations))
+	}
+
+	if len(errOps.Messages) != 0 {
+		errValidation.Fields = append(errValidation.Fields, errOps)
+	}
+
+	if len(errValidation.Fields) != 0 {
+		return "", errValidation
+	}
+
+	return mode, nil
+}
+
+// Batch applies a list of task operations, returning the result of each one.
+// Errors of the operations are reported in their results, the returned error
+// is only for an invalid request or an unexpected failure in atomic mode.
+func (svc TaskService) Batch(ctx context.Context, accId int, req dto.BatchTaskRequest) (dto.BatchTaskResponse, error) {
+	mode, err := validateBatchRequest(req)
+	if err != nil {
+		return dto.BatchTaskResponse{}, err
+	}
+
+	resp := dto.BatchTaskResponse{
+		Mode:    mode,
+		Results: make([]dto.BatchTaskResult, len(req.Operations)),
+	}
+
+	if mode == BatchModeBestEffort {
+		for i, op := range req.Operations {
+			result, err := svc.applyBatchOperation(ctx, accId, i, op)
+			if err != nil {
+				// The operations before are already applied, so
+				// the unexpected failure is reported like the others
+				errInternal := common.ErrInternal
+				result = dto.BatchTaskResult{Index: i, Status: BatchStatusFailed, Error: &errInternal}
+			}
+
+			resp.Results[i] = result
+		}
+
+		return resp, nil
+	}
+
+	failed := -1
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for i, op := range req.Operations {
+			result, err := svc.applyBatchOperation(ctx, accId, i, op)
+			if err != nil {
+				return err
+			}
+
+			resp.Results[i] = result
+			if result.Status == BatchStatusFailed {
+				failed = i
+				return errBatchAborted
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errBatchAborted) {
+		return dto.BatchTaskResponse{}, err
+	}
+
+	if failed != -1 {
+		for i := range resp.Results {
+			switch {
+			case i < failed:
+				resp.Results[i] = dto.BatchTaskResult{Index: i, Status: BatchStatusRolledBack}
+
+			case i > failed:
+				resp.Results[i] = dto.BatchTaskResult{Index: i, Status: BatchStatusSkipped}
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// applyBatchOperation applies a single operation. Client errors, such as
+// invalid input or a missing task, are reported in the result.
+func (svc TaskService) applyBatchOperation(ctx context.Context, accId, index int, op dto.BatchTaskOperation) (dto.BatchTaskResult, error) {
+	var task *dto.Task
+	var err error
+
+	switch op.Op {
+	case BatchOpCreate:
+		if op.Create == nil {
+			err = batchFieldError("create", "create is required for create operations")
+			break
+		}
+
+		var created dto.Task
+		created, err = svc.CreateTask(ctx, accId, *op.Create)
+		task = &created
+
+	case BatchOpUpdate:
+		if op.Update == nil {
+			err = batchFieldError("update", "update is required for update operations")
+			break
+		}
+
+		req := *op.Update
+		req.ID = op.ID
+		req.Version = op.Version
+		req.Scope = op.Scope
+
+		var updated dto.Task
+		updated, err = svc.Update(ctx, accId, req)
+		task = &updated
+
+	case BatchOpDelete:
+		err = svc.Delete(ctx, accId, op.ID, op.Version)
+
+	default:
+		err = batchFieldError("op", "invalid op, valid ops are: create, update, and delete")
+	}
+
+	if err != nil {
+		var xErr common.Error
+		if !errors.As(err, &xErr) {
+			return dto.BatchTaskResult{}, err
+		}
+
+		return dto.BatchTaskResult{Index: index, Status: BatchStatusFailed, Error: &xErr}, nil
+	}
+
+	return dto.BatchTaskResult{Index: index, Status: BatchStatusOK, Task: task}, nil
+}
+
+func batchFieldError(field, msg string) error {
+	return common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid input",
+		Fields:  []common.FieldError{{Name: field, Messages: []string{msg}}},
+	}
+}
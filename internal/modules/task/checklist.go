@@ -0,0 +1,236 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/vinovest/sqlx"
+)
+
+// ChecklistItem is a step of a task that does not need to be a subtask.
+// Items are ordered by Position, a rank from the rank package.
+type ChecklistItem struct {
+	ID        int       `db:"id"`
+	TaskID    int       `db:"task_id"`
+	AccountID int       `db:"account_id"`
+	Text      string    `db:"text"`
+	Checked   bool      `db:"checked"`
+	Position  string    `db:"position"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type ChecklistProgress struct {
+	Done  int `db:"done"`
+	Total int `db:"total"`
+}
+
+func NewChecklistItem(accId, taskId int, req dto.CreateChecklistItemRequest) (ChecklistItem, error) {
+	text := strings.TrimSpace(req.Text)
+
+	var errText common.FieldError
+	if len(text) == 0 {
+		errText.Messages = append(errText.Messages, "text can not be empty")
+	}
+
+	if len(text) > 500 {
+		errText.Messages = append(errText.Messages, "text can not be longer than 500 characters")
+	}
+
+	if len(errText.Messages) != 0 {
+		errText.Name = "text"
+		return ChecklistItem{}, common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  []common.FieldError{errText},
+		}
+	}
+
+	return ChecklistItem{
+		TaskID:    taskId,
+		AccountID: accId,
+		Text:      text,
+	}, nil
+}
+
+type ChecklistRepository interface {
+	Save(ctx context.Context, item ChecklistItem) (int, error)
+	GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int) ([]ChecklistItem, error)
+	GetByAccountIDAndID(ctx context.Context, accId, taskId, id int) (ChecklistItem, error)
+	// GetLastPosition returns the highest position of the task's items,
+	// empty if the task has no items
+	GetLastPosition(ctx context.Context, taskId int) (string, error)
+	// GetAdjacentPosition returns the closest position after the given position,
+	// or before it if before is true, among the task's items other than id.
+	// Empty is returned if there is no such item.
+	GetAdjacentPosition(ctx context.Context, taskId, id int, position string, before bool) (string, error)
+	UpdatePositionByAccountIDAndID(ctx context.Context, accId, taskId, id int, position string) error
+	// ToggleByAccountIDAndID flips the checked state of an item
+	ToggleByAccountIDAndID(ctx context.Context, accId, taskId, id int) error
+	DeleteByAccountIDAndID(ctx context.Context, accId, taskId, id int) error
+	GetProgressByTaskIDs(ctx context.Context, taskIds []int) (map[int]ChecklistProgress, error)
+}
+
+type PostgreChecklistRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreChecklistRepository(db *sqlx.DB, logger *slog.Logger) PostgreChecklistRepository {
+	return PostgreChecklistRepository{db: db, logger: logger}
+}
+
+func (repo PostgreChecklistRepository) Save(ctx context.Context, item ChecklistItem) (int, error) {
+	q := `INSERT INTO task_checklist_items (task_id, account_id, text, position) VALUES ($1, $2, $3, $4) RETURNING id`
+
+	var id int
+	err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, item.TaskID, item.AccountID, item.Text, item.Position).Scan(&id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving checklist item: %v", err), slog.Int("task_id", item.TaskID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreChecklistRepository) GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int) ([]ChecklistItem, error) {
+	q := `SELECT id, task_id, account_id, text, checked, position, created_at, updated_at
+		FROM task_checklist_items
+		WHERE account_id = $1 AND task_id = $2
+		ORDER BY position, id`
+
+	var items []ChecklistItem
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &items, q, accId, taskId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching checklist items: %v", err), slog.Int("task_id", taskId))
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (repo PostgreChecklistRepository) GetByAccountIDAndID(ctx context.Context, accId, taskId, id int) (ChecklistItem, error) {
+	q := `SELECT id, task_id, account_id, text, checked, position, created_at, updated_at
+		FROM task_checklist_items WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	var item ChecklistItem
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &item, q, accId, taskId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ChecklistItem{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a checklist item: %v", err), slog.Int("task_id", taskId), slog.Int("id", id))
+		return ChecklistItem{}, err
+	}
+
+	return item, nil
+}
+
+func (repo PostgreChecklistRepository) GetLastPosition(ctx context.Context, taskId int) (string, error) {
+	q := `SELECT COALESCE(MAX(position), '') FROM task_checklist_items WHERE task_id = $1`
+
+	var position string
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, taskId).Scan(&position); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching last checklist position: %v", err), slog.Int("task_id", taskId))
+		return "", err
+	}
+
+	return position, nil
+}
+
+func (repo PostgreChecklistRepository) GetAdjacentPosition(ctx context.Context, taskId, id int, position string, before bool) (string, error) {
+	cmp, dir := ">", "ASC"
+	if before {
+		cmp, dir = "<", "DESC"
+	}
+
+	q := `SELECT COALESCE((SELECT position FROM task_checklist_items
+		WHERE task_id = $1 AND id <> $2 AND position ` + cmp + ` $3
+		ORDER BY position ` + dir + ` LIMIT 1), '')`
+
+	var adjacent string
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, taskId, id, position).Scan(&adjacent); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching adjacent checklist position: %v", err), slog.Int("id", id))
+		return "", err
+	}
+
+	return adjacent, nil
+}
+
+func (repo PostgreChecklistRepository) UpdatePositionByAccountIDAndID(ctx context.Context, accId, taskId, id int, position string) error {
+	q := `UPDATE task_checklist_items SET position = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $2 AND task_id = $3 AND id = $4`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, position, accId, taskId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating checklist item position: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreChecklistRepository) ToggleByAccountIDAndID(ctx context.Context, accId, taskId, id int) error {
+	q := `UPDATE task_checklist_items SET checked = NOT checked, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, taskId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on toggling a checklist item: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreChecklistRepository) DeleteByAccountIDAndID(ctx context.Context, accId, taskId, id int) error {
+	q := `DELETE FROM task_checklist_items WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, taskId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting a checklist item: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreChecklistRepository) GetProgressByTaskIDs(ctx context.Context, taskIds []int) (map[int]ChecklistProgress, error) {
+	progress := make(map[int]ChecklistProgress)
+	if len(taskIds) == 0 {
+		return progress, nil
+	}
+
+	q := `SELECT task_id, COUNT(*) FILTER (WHERE checked) AS done, COUNT(*) AS total
+		FROM task_checklist_items
+		WHERE task_id = ANY($1)
+		GROUP BY task_id`
+
+	rows, err := database.Conn(ctx, repo.db).QueryxContext(ctx, q, taskIds)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching checklist progress: %v", err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId int
+		var p ChecklistProgress
+		if err := rows.Scan(&taskId, &p.Done, &p.Total); err != nil {
+			repo.logger.Error(fmt.Sprintf("error on scanning checklist progress: %v", err))
+			return nil, err
+		}
+
+		progress[taskId] = p
+	}
+
+	return progress, rows.Err()
+}
@@ -0,0 +1,217 @@
+package task
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/pkg/rank"
+)
+
+func (svc TaskService) GetChecklist(ctx context.Context, accId, id int) ([]dto.ChecklistItem, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+		return nil, err
+	}
+
+	items, err := svc.checklistRepo.GetByAccountIDAndTaskID(ctx, accId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	itemDtos := []dto.ChecklistItem{}
+	for _, item := range items {
+		itemDtos = append(itemDtos, checklistItemToChecklistItemDTO(item))
+	}
+
+	return itemDtos, nil
+}
+
+// AddChecklistItem adds an item at the end of the task's checklist
+func (svc TaskService) AddChecklistItem(ctx context.Context, accId, id int, req dto.CreateChecklistItemRequest) (dto.ChecklistItem, error) {
+	item, err := NewChecklistItem(accId, id, req)
+	if err != nil {
+		return dto.ChecklistItem{}, err
+	}
+
+	var created ChecklistItem
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		last, err := svc.checklistRepo.GetLastPosition(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		item.Position, err = rank.Between(last, "")
+		if err != nil {
+			return err
+		}
+
+		itemId, err := svc.checklistRepo.Save(ctx, item)
+		if err != nil {
+			return err
+		}
+
+		created, err = svc.checklistRepo.GetByAccountIDAndID(ctx, accId, id, itemId)
+
+		return err
+	})
+
+	if err != nil {
+		return dto.ChecklistItem{}, err
+	}
+
+	return checklistItemToChecklistItemDTO(created), nil
+}
+
+func validateMoveChecklistItemRequest(itemId int, req dto.MoveChecklistItemRequest) error {
+	if fields := validateMoveAnchors(itemId, req.BeforeID, req.AfterID, "an item"); len(fields) != 0 {
+		return common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  fields,
+		}
+	}
+
+	return nil
+}
+
+// MoveChecklistItem places an item right before or after another item
+// of the same checklist, or at the end of it if neither is given
+func (svc TaskService) MoveChecklistItem(ctx context.Context, accId, id, itemId int, req dto.MoveChecklistItemRequest) (dto.ChecklistItem, error) {
+	if err := validateMoveChecklistItemRequest(itemId, req); err != nil {
+		return dto.ChecklistItem{}, err
+	}
+
+	var moved ChecklistItem
+	err := svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		if _, err := svc.checklistRepo.GetByAccountIDAndID(ctx, accId, id, itemId); err != nil {
+			return err
+		}
+
+		var lo, hi string
+		switch {
+		case req.AfterID != nil:
+			anchor, err := svc.getChecklistAnchor(ctx, accId, id, *req.AfterID, "after_id")
+			if err != nil {
+				return err
+			}
+
+			lo = anchor.Position
+			hi, err = svc.checklistRepo.GetAdjacentPosition(ctx, id, itemId, anchor.Position, false)
+			if err != nil {
+				return err
+			}
+
+		case req.BeforeID != nil:
+			anchor, err := svc.getChecklistAnchor(ctx, accId, id, *req.BeforeID, "before_id")
+			if err != nil {
+				return err
+			}
+
+			hi = anchor.Position
+			lo, err = svc.checklistRepo.GetAdjacentPosition(ctx, id, itemId, anchor.Position, true)
+			if err != nil {
+				return err
+			}
+
+		default:
+			var err error
+			lo, err = svc.checklistRepo.GetLastPosition(ctx, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		position, err := rank.Between(lo, hi)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.checklistRepo.UpdatePositionByAccountIDAndID(ctx, accId, id, itemId, position); err != nil {
+			return err
+		}
+
+		moved, err = svc.checklistRepo.GetByAccountIDAndID(ctx, accId, id, itemId)
+
+		return err
+	})
+
+	if err != nil {
+		return dto.ChecklistItem{}, err
+	}
+
+	return checklistItemToChecklistItemDTO(moved), nil
+}
+
+// getChecklistAnchor fetches the item another item is moved next to
+func (svc TaskService) getChecklistAnchor(ctx context.Context, accId, id, anchorId int, field string) (ChecklistItem, error) {
+	anchor, err := svc.checklistRepo.GetByAccountIDAndID(ctx, accId, id, anchorId)
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			return ChecklistItem{}, common.Error{
+				Code:    common.ErrCodeInputValidation,
+				Message: "Invalid input",
+				Fields:  []common.FieldError{{Name: field, Messages: []string{"item does not exist in the checklist"}}},
+			}
+		}
+
+		return ChecklistItem{}, err
+	}
+
+	return anchor, nil
+}
+
+// ToggleChecklistItem checks an unchecked item, or unchecks a checked one
+func (svc TaskService) ToggleChecklistItem(ctx context.Context, accId, id, itemId int) (dto.ChecklistItem, error) {
+	var toggled ChecklistItem
+	err := svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		if err := svc.checklistRepo.ToggleByAccountIDAndID(ctx, accId, id, itemId); err != nil {
+			return err
+		}
+
+		var err error
+		toggled, err = svc.checklistRepo.GetByAccountIDAndID(ctx, accId, id, itemId)
+
+		return err
+	})
+
+	if err != nil {
+		return dto.ChecklistItem{}, err
+	}
+
+	return checklistItemToChecklistItemDTO(toggled), nil
+}
+
+func (svc TaskService) DeleteChecklistItem(ctx context.Context, accId, id, itemId int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		return svc.checklistRepo.DeleteByAccountIDAndID(ctx, accId, id, itemId)
+	})
+}
+
+func checklistItemToChecklistItemDTO(item ChecklistItem) dto.ChecklistItem {
+	return dto.ChecklistItem{
+		ID:        item.ID,
+		TaskID:    item.TaskID,
+		Text:      item.Text,
+		Checked:   item.Checked,
+		Position:  item.Position,
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}
+}
@@ -0,0 +1,207 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/vinovest/sqlx"
+)
+
+const maxCommentLength = 10000
+
+// Comment is a comment on a task. Body is Markdown, stored as written.
+type Comment struct {
+	ID        int       `db:"id"`
+	TaskID    int       `db:"task_id"`
+	AccountID int       `db:"account_id"`
+	AuthorID  int       `db:"author_id"`
+	Body      string    `db:"body"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+	// EditedAt is when the body was last changed, nil if it never was
+	EditedAt *time.Time `db:"edited_at"`
+}
+
+func validateCommentBody(body string) *common.FieldError {
+	errBody := common.FieldError{Name: "body"}
+	if strings.TrimSpace(body) == "" {
+		errBody.Messages = append(errBody.Messages, "body can not be empty")
+	}
+
+	if len(body) > maxCommentLength {
+		errBody.Messages = append(errBody.Messages, fmt.Sprintf("body can not be longer than %d characters", maxCommentLength))
+	}
+
+	if len(errBody.Messages) != 0 {
+		return &errBody
+	}
+
+	return nil
+}
+
+func NewComment(accId, taskId int, req dto.CreateCommentRequest) (Comment, error) {
+	if errBody := validateCommentBody(req.Body); errBody != nil {
+		return Comment{}, common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  []common.FieldError{*errBody},
+		}
+	}
+
+	return Comment{
+		TaskID:    taskId,
+		AccountID: accId,
+		AuthorID:  accId,
+		Body:      req.Body,
+	}, nil
+}
+
+type CommentList struct {
+	Comments   []Comment
+	Pagination dto.PaginationMetadata
+}
+
+type CommentRepository interface {
+	Save(ctx context.Context, comment Comment) (int, error)
+	GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int, paginate dto.Pagination) (CommentList, error)
+	GetByAccountIDAndID(ctx context.Context, accId, taskId, id int) (Comment, error)
+	// UpdateBodyByAccountIDAndID changes the body of a comment and marks it as edited
+	UpdateBodyByAccountIDAndID(ctx context.Context, accId, taskId, id int, body string) error
+	DeleteByAccountIDAndID(ctx context.Context, accId, taskId, id int) error
+	// GetCountsByTaskIDs returns the number of comments keyed by task ID
+	GetCountsByTaskIDs(ctx context.Context, taskIds []int) (map[int]int, error)
+}
+
+type PostgreCommentRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreCommentRepository(db *sqlx.DB, logger *slog.Logger) PostgreCommentRepository {
+	return PostgreCommentRepository{db: db, logger: logger}
+}
+
+func (repo PostgreCommentRepository) Save(ctx context.Context, comment Comment) (int, error) {
+	q := `INSERT INTO task_comments (task_id, account_id, author_id, body) VALUES ($1, $2, $3, $4) RETURNING id`
+
+	var id int
+	err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, comment.TaskID, comment.AccountID, comment.AuthorID, comment.Body).Scan(&id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving task comment: %v", err), slog.Int("task_id", comment.TaskID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreCommentRepository) GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int, paginate dto.Pagination) (CommentList, error) {
+	conn := database.Conn(ctx, repo.db)
+
+	// Comments read as a conversation, oldest first
+	q := `SELECT id, task_id, account_id, author_id, body, created_at, updated_at, edited_at
+		FROM task_comments
+		WHERE account_id = $1 AND task_id = $2
+		ORDER BY created_at, id
+		LIMIT $3 OFFSET $4`
+
+	var comments []Comment
+	err := conn.SelectContext(ctx, &comments, q, accId, taskId, paginate.PageSize, common.GetOffset(paginate.Page, paginate.PageSize))
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task comments: %v", err), slog.Int("task_id", taskId))
+		return CommentList{}, err
+	}
+
+	q = `SELECT COUNT(id) FROM task_comments WHERE account_id = $1 AND task_id = $2`
+	var totalCount int
+	if err := conn.QueryRowContext(ctx, q, accId, taskId).Scan(&totalCount); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task comment count: %v", err), slog.Int("task_id", taskId))
+		return CommentList{}, err
+	}
+
+	return CommentList{
+		Comments: comments,
+		Pagination: dto.PaginationMetadata{
+			Pagination: paginate,
+			Total:      totalCount,
+		},
+	}, nil
+}
+
+func (repo PostgreCommentRepository) GetByAccountIDAndID(ctx context.Context, accId, taskId, id int) (Comment, error) {
+	q := `SELECT id, task_id, account_id, author_id, body, created_at, updated_at, edited_at
+		FROM task_comments WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	var comment Comment
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &comment, q, accId, taskId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Comment{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a task comment: %v", err), slog.Int("task_id", taskId), slog.Int("id", id))
+		return Comment{}, err
+	}
+
+	return comment, nil
+}
+
+func (repo PostgreCommentRepository) UpdateBodyByAccountIDAndID(ctx context.Context, accId, taskId, id int, body string) error {
+	q := `UPDATE task_comments SET body = $1, edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $2 AND task_id = $3 AND id = $4`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, body, accId, taskId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating a task comment: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreCommentRepository) DeleteByAccountIDAndID(ctx context.Context, accId, taskId, id int) error {
+	q := `DELETE FROM task_comments WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, taskId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting a task comment: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreCommentRepository) GetCountsByTaskIDs(ctx context.Context, taskIds []int) (map[int]int, error) {
+	counts := make(map[int]int)
+	if len(taskIds) == 0 {
+		return counts, nil
+	}
+
+	q := `SELECT task_id, COUNT(*) FROM task_comments WHERE task_id = ANY($1) GROUP BY task_id`
+
+	rows, err := database.Conn(ctx, repo.db).QueryxContext(ctx, q, taskIds)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task comment counts: %v", err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId, count int
+		if err := rows.Scan(&taskId, &count); err != nil {
+			repo.logger.Error(fmt.Sprintf("error on scanning task comment count: %v", err))
+			return nil, err
+		}
+
+		counts[taskId] = count
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,123 @@
+package task
+
+import (
+	"context"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+func (svc TaskService) GetComments(ctx context.Context, accId, id int, paginate dto.Pagination) (dto.CommentList, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+		return dto.CommentList{}, err
+	}
+
+	commentList, err := svc.commentRepo.GetByAccountIDAndTaskID(ctx, accId, id, paginate)
+	if err != nil {
+		return dto.CommentList{}, err
+	}
+
+	comments := dto.CommentList{
+		Comments:   []dto.Comment{},
+		Pagination: commentList.Pagination,
+	}
+
+	for _, comment := range commentList.Comments {
+		comments.Comments = append(comments.Comments, commentToCommentDTO(comment))
+	}
+
+	return comments, nil
+}
+
+func (svc TaskService) AddComment(ctx context.Context, accId, id int, req dto.CreateCommentRequest) (dto.Comment, error) {
+	comment, err := NewComment(accId, id, req)
+	if err != nil {
+		return dto.Comment{}, err
+	}
+
+	var created Comment
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		commentId, err := svc.commentRepo.Save(ctx, comment)
+		if err != nil {
+			return err
+		}
+
+		created, err = svc.commentRepo.GetByAccountIDAndID(ctx, accId, id, commentId)
+
+		return err
+	})
+
+	if err != nil {
+		return dto.Comment{}, err
+	}
+
+	return commentToCommentDTO(created), nil
+}
+
+func (svc TaskService) UpdateComment(ctx context.Context, accId, id, commentId int, req dto.UpdateCommentRequest) (dto.Comment, error) {
+	if errBody := validateCommentBody(req.Body); errBody != nil {
+		return dto.Comment{}, common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  []common.FieldError{*errBody},
+		}
+	}
+
+	var updated Comment
+	err := svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.getComment(ctx, accId, id, commentId); err != nil {
+			return err
+		}
+
+		if err := svc.commentRepo.UpdateBodyByAccountIDAndID(ctx, accId, id, commentId, req.Body); err != nil {
+			return err
+		}
+
+		var err error
+		updated, err = svc.commentRepo.GetByAccountIDAndID(ctx, accId, id, commentId)
+
+		return err
+	})
+
+	if err != nil {
+		return dto.Comment{}, err
+	}
+
+	return commentToCommentDTO(updated), nil
+}
+
+func (svc TaskService) DeleteComment(ctx context.Context, accId, id, commentId int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.getComment(ctx, accId, id, commentId); err != nil {
+			return err
+		}
+
+		return svc.commentRepo.DeleteByAccountIDAndID(ctx, accId, id, commentId)
+	})
+}
+
+// getComment fetches a comment on a task that is not in the trash.
+// Tasks are only reachable by their account, which wrote all of their comments.
+func (svc TaskService) getComment(ctx context.Context, accId, id, commentId int) (Comment, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+		return Comment{}, err
+	}
+
+	return svc.commentRepo.GetByAccountIDAndID(ctx, accId, id, commentId)
+}
+
+func commentToCommentDTO(comment Comment) dto.Comment {
+	return dto.Comment{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		AuthorID:  comment.AuthorID,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+		EditedAt:  comment.EditedAt,
+	}
+}
@@ -0,0 +1,168 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/vinovest/sqlx"
+)
+
+// Dependency means the task BlockerID blocks the task BlockedID,
+// so BlockedID can not be done while BlockerID is still open
+type Dependency struct {
+	BlockerID int `db:"blocker_id"`
+	BlockedID int `db:"blocked_id"`
+}
+
+// isOpenStatus reports whether a task with the status still needs work
+func isOpenStatus(status string) bool {
+	return status != StatusDone && status != StatusAbandoned
+}
+
+type DependencyRepository interface {
+	Save(ctx context.Context, dep Dependency) error
+	Delete(ctx context.Context, dep Dependency) error
+	// GetBlockers returns the tasks blocking the task
+	GetBlockers(ctx context.Context, blockedId int) ([]Task, error)
+	// GetBlocked returns the tasks blocked by the task
+	GetBlocked(ctx context.Context, blockerId int) ([]Task, error)
+	// GetBlockedByTasksDeletedBefore returns the tasks blocked by the tasks
+	// moved to the trash before a time, of all accounts
+	GetBlockedByTasksDeletedBefore(ctx context.Context, before time.Time) ([]Task, error)
+	CountOpenBlockers(ctx context.Context, blockedId int) (int, error)
+	// IsBlockedBy reports whether blockerId blocks blockedId,
+	// either directly or through other tasks
+	IsBlockedBy(ctx context.Context, blockedId, blockerId int) (bool, error)
+	// LockByAccountID serializes the transactions adding dependencies
+	// between the account's tasks, until the transaction ends
+	LockByAccountID(ctx context.Context, accId int) error
+}
+
+type PostgreDependencyRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreDependencyRepository(db *sqlx.DB, logger *slog.Logger) PostgreDependencyRepository {
+	return PostgreDependencyRepository{db: db, logger: logger}
+}
+
+func (repo PostgreDependencyRepository) Save(ctx context.Context, dep Dependency) error {
+	q := `INSERT INTO task_dependencies (blocker_id, blocked_id) VALUES (:blocker_id, :blocked_id)
+		ON CONFLICT DO NOTHING`
+
+	_, err := database.Conn(ctx, repo.db).NamedExecContext(ctx, q, dep)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving task dependency: %v", err), slog.Any("dependency", dep))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreDependencyRepository) Delete(ctx context.Context, dep Dependency) error {
+	q := `DELETE FROM task_dependencies WHERE blocker_id = $1 AND blocked_id = $2`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, dep.BlockerID, dep.BlockedID)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting task dependency: %v", err), slog.Any("dependency", dep))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreDependencyRepository) GetBlockers(ctx context.Context, blockedId int) ([]Task, error) {
+	q := `SELECT ` + prefixColumns("t", taskListColumns) + ` FROM task_dependencies d
+		JOIN tasks t ON t.id = d.blocker_id
+		WHERE d.blocked_id = $1 AND t.deleted_at IS NULL
+		ORDER BY t.id`
+
+	var tasks []Task
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &tasks, q, blockedId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task blockers: %v", err), slog.Int("task_id", blockedId))
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (repo PostgreDependencyRepository) GetBlocked(ctx context.Context, blockerId int) ([]Task, error) {
+	q := `SELECT ` + prefixColumns("t", taskListColumns) + ` FROM task_dependencies d
+		JOIN tasks t ON t.id = d.blocked_id
+		WHERE d.blocker_id = $1 AND t.deleted_at IS NULL
+		ORDER BY t.id`
+
+	var tasks []Task
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &tasks, q, blockerId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching tasks blocked by a task: %v", err), slog.Int("task_id", blockerId))
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (repo PostgreDependencyRepository) GetBlockedByTasksDeletedBefore(ctx context.Context, before time.Time) ([]Task, error) {
+	q := `SELECT DISTINCT t.account_id, ` + prefixColumns("t", taskListColumns) + ` FROM task_dependencies d
+		JOIN tasks b ON b.id = d.blocker_id
+		JOIN tasks t ON t.id = d.blocked_id
+		WHERE b.deleted_at < $1 AND t.deleted_at IS NULL
+		ORDER BY t.id`
+
+	var tasks []Task
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &tasks, q, before.UTC()); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching tasks blocked by deleted tasks: %v", err))
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (repo PostgreDependencyRepository) CountOpenBlockers(ctx context.Context, blockedId int) (int, error) {
+	q := `SELECT COUNT(*) FROM task_dependencies d
+		JOIN tasks t ON t.id = d.blocker_id
+		WHERE d.blocked_id = $1 AND t.deleted_at IS NULL AND t.status NOT IN ($2, $3)`
+
+	var count int
+	row := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, blockedId, StatusDone, StatusAbandoned)
+	if err := row.Scan(&count); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on counting open task blockers: %v", err), slog.Int("task_id", blockedId))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo PostgreDependencyRepository) IsBlockedBy(ctx context.Context, blockedId, blockerId int) (bool, error) {
+	q := `WITH RECURSIVE blockers AS (
+		SELECT blocker_id FROM task_dependencies WHERE blocked_id = $1
+		UNION
+		SELECT d.blocker_id FROM task_dependencies d
+		JOIN blockers b ON d.blocked_id = b.blocker_id
+	)
+	SELECT EXISTS (SELECT 1 FROM blockers WHERE blocker_id = $2)`
+
+	var exists bool
+	row := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, blockedId, blockerId)
+	if err := row.Scan(&exists); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on checking task dependency path: %v", err), slog.Int("blocked_id", blockedId), slog.Int("blocker_id", blockerId))
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (repo PostgreDependencyRepository) LockByAccountID(ctx context.Context, accId int) error {
+	q := `SELECT pg_advisory_xact_lock(hashtext('task_dependencies'), $1::int)`
+
+	if _, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on locking task dependencies: %v", err), slog.Int("account_id", accId))
+		return err
+	}
+
+	return nil
+}
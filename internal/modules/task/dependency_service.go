@@ -0,0 +1,210 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+var (
+	ErrDependencyCycle = common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid input",
+		Fields: []common.FieldError{
+			{Name: "blocker_id", Messages: []string{"the dependency would create a cycle"}},
+		},
+	}
+	ErrTaskBlocked = common.Error{
+		Code:    common.ErrCodeConflict,
+		Message: "Task can not be done while it is blocked by open tasks",
+	}
+)
+
+func (svc TaskService) GetDependencies(ctx context.Context, accId, id int) (dto.TaskDependencies, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+		return dto.TaskDependencies{}, err
+	}
+
+	blockers, err := svc.depRepo.GetBlockers(ctx, id)
+	if err != nil {
+		return dto.TaskDependencies{}, err
+	}
+
+	blocked, err := svc.depRepo.GetBlocked(ctx, id)
+	if err != nil {
+		return dto.TaskDependencies{}, err
+	}
+
+	now := time.Now()
+	deps := dto.TaskDependencies{
+		BlockedBy: []dto.Task{},
+		Blocking:  []dto.Task{},
+	}
+
+	for _, task := range blockers {
+		deps.BlockedBy = append(deps.BlockedBy, taskToTaskDTO(task, now))
+	}
+
+	for _, task := range blocked {
+		deps.Blocking = append(deps.Blocking, taskToTaskDTO(task, now))
+	}
+
+	return deps, nil
+}
+
+// AddDependency makes the task blocked by another task.
+// An open task gets flagged as blocked while the blocker is still open.
+func (svc TaskService) AddDependency(ctx context.Context, accId, id int, req dto.AddDependencyRequest) error {
+	dep := Dependency{BlockerID: req.BlockerID, BlockedID: id}
+	if dep.BlockerID == dep.BlockedID {
+		return ErrDependencyCycle
+	}
+
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Concurrent additions could each pass the cycle check below
+		// and make a cycle together
+		if err := svc.depRepo.LockByAccountID(ctx, accId); err != nil {
+			return err
+		}
+
+		blocked, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, dep.BlockedID)
+		if err != nil {
+			return err
+		}
+
+		blocker, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, dep.BlockerID)
+		if err != nil {
+			return err
+		}
+
+		// The blocker must not be waiting for the blocked task already
+		cycle, err := svc.depRepo.IsBlockedBy(ctx, dep.BlockerID, dep.BlockedID)
+		if err != nil {
+			return err
+		}
+
+		if cycle {
+			return ErrDependencyCycle
+		}
+
+		if err := svc.depRepo.Save(ctx, dep); err != nil {
+			return err
+		}
+
+		return svc.blockIfOpen(ctx, accId, blocker, blocked)
+	})
+}
+
+// blockIfOpen flags a task waiting for an open blocker as blocked
+func (svc TaskService) blockIfOpen(ctx context.Context, accId int, blocker, blocked Task) error {
+	if isOpenStatus(blocker.Status) && (blocked.Status == StatusTODO || blocked.Status == StatusInProgress) {
+		return svc.updateStatus(ctx, accId, blocked, StatusBlocked)
+	}
+
+	return nil
+}
+
+func (svc TaskService) RemoveDependency(ctx context.Context, accId, id, blockerId int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		if err := svc.depRepo.Delete(ctx, Dependency{BlockerID: blockerId, BlockedID: id}); err != nil {
+			return err
+		}
+
+		return svc.unblockIfReady(ctx, accId, id)
+	})
+}
+
+// checkBlockers returns ErrTaskBlocked if the task still has open blockers
+func (svc TaskService) checkBlockers(ctx context.Context, id int) error {
+	count, err := svc.depRepo.CountOpenBlockers(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if count != 0 {
+		return ErrTaskBlocked
+	}
+
+	return nil
+}
+
+// releaseBlocked unblocks the tasks blocked by a task that has just been
+// closed or moved to the trash, if they are not waiting for any other open task
+func (svc TaskService) releaseBlocked(ctx context.Context, accId, id int) error {
+	blocked, err := svc.depRepo.GetBlocked(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return svc.unblockAllIfReady(ctx, accId, blocked)
+}
+
+func (svc TaskService) unblockAllIfReady(ctx context.Context, accId int, tasks []Task) error {
+	for _, task := range tasks {
+		if err := svc.unblockIfReady(ctx, accId, task.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// blockDependents flags the tasks waiting for a task that has just
+// been restored from the trash as blocked again, if it is still open
+func (svc TaskService) blockDependents(ctx context.Context, accId int, task Task) error {
+	blocked, err := svc.depRepo.GetBlocked(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, dependent := range blocked {
+		if err := svc.blockIfOpen(ctx, accId, task, dependent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unblockIfReady moves a blocked task back to todo
+// once all of its blockers are closed
+func (svc TaskService) unblockIfReady(ctx context.Context, accId, id int) error {
+	task, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id)
+	if err != nil {
+		return err
+	}
+
+	if task.Status != StatusBlocked {
+		return nil
+	}
+
+	count, err := svc.depRepo.CountOpenBlockers(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if count != 0 {
+		return nil
+	}
+
+	return svc.updateStatus(ctx, accId, task, StatusTODO)
+}
+
+// updateStatus changes the status of a task on behalf of the account,
+// bypassing the transition table as it is done automatically
+func (svc TaskService) updateStatus(ctx context.Context, accId int, task Task, status string) error {
+	if err := svc.taskRepo.UpdateStatusByAccountIDAndID(ctx, accId, task.ID, status); err != nil {
+		return err
+	}
+
+	updated := task
+	updated.Status = status
+
+	return svc.recordChanges(ctx, accId, HistoryActionUpdate, task, updated)
+}
@@ -0,0 +1,183 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/vinovest/sqlx"
+)
+
+// History actions
+const (
+	HistoryActionCreate  = "create"
+	HistoryActionUpdate  = "update"
+	HistoryActionDelete  = "delete"
+	HistoryActionRestore = "restore"
+	HistoryActionPurge   = "purge"
+)
+
+// HistoryEntry is an immutable record of a change made to a task.
+// Field, OldValue and NewValue are nil when the change
+// is not about a single field, such as deleting the task.
+type HistoryEntry struct {
+	ID        int       `db:"id"`
+	TaskID    int       `db:"task_id"`
+	AccountID int       `db:"account_id"`
+	ActorID   int       `db:"actor_id"`
+	Action    string    `db:"action"`
+	Field     *string   `db:"field"`
+	OldValue  *string   `db:"old_value"`
+	NewValue  *string   `db:"new_value"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type historyField struct {
+	name  string
+	value func(task Task) *string
+}
+
+func stringValue(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func timeValue(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	return stringValue(t.UTC().Format(time.RFC3339))
+}
+
+func intValue(i *int) *string {
+	if i == nil {
+		return nil
+	}
+
+	return stringValue(strconv.Itoa(*i))
+}
+
+// historyFields are the task fields tracked in the history
+var historyFields = []historyField{
+	{"title", func(task Task) *string { return stringValue(task.Title) }},
+	{"description", func(task Task) *string { return stringValue(task.Description) }},
+	{"status", func(task Task) *string { return stringValue(task.Status) }},
+	{"priority", func(task Task) *string { return stringValue(task.Priority.String()) }},
+	{"parent_id", func(task Task) *string { return intValue(task.ParentID) }},
+	{"project_id", func(task Task) *string { return intValue(task.ProjectID) }},
+	{"start_at", func(task Task) *string { return timeValue(task.StartAt) }},
+	{"due_at", func(task Task) *string { return timeValue(task.DueAt) }},
+	{"estimate_seconds", func(task Task) *string { return intValue(task.Estimate) }},
+	{"tags", func(task Task) *string { return stringValue(strings.Join(task.Tags, ", ")) }},
+	{"recurrence", func(task Task) *string { return stringValue(task.Recurrence) }},
+}
+
+func equalValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// diffTask returns the history entries of the fields changed from before to after
+func diffTask(actorId int, action string, before, after Task) []HistoryEntry {
+	var entries []HistoryEntry
+	for _, field := range historyFields {
+		oldVal := field.value(before)
+		newVal := field.value(after)
+		if equalValues(oldVal, newVal) {
+			continue
+		}
+
+		entries = append(entries, HistoryEntry{
+			TaskID:    after.ID,
+			AccountID: after.AccountID,
+			ActorID:   actorId,
+			Action:    action,
+			Field:     &field.name,
+			OldValue:  oldVal,
+			NewValue:  newVal,
+		})
+	}
+
+	return entries
+}
+
+type HistoryList struct {
+	Entries    []HistoryEntry
+	Pagination dto.PaginationMetadata
+}
+
+type HistoryRepository interface {
+	Save(ctx context.Context, entries []HistoryEntry) error
+	GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int, paginate dto.Pagination) (HistoryList, error)
+}
+
+type PostgreHistoryRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreHistoryRepository(db *sqlx.DB, logger *slog.Logger) PostgreHistoryRepository {
+	return PostgreHistoryRepository{db: db, logger: logger}
+}
+
+func (repo PostgreHistoryRepository) Save(ctx context.Context, entries []HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	q := `INSERT INTO task_history (task_id, account_id, actor_id, action, field, old_value, new_value)
+		VALUES (:task_id, :account_id, :actor_id, :action, :field, :old_value, :new_value)`
+
+	_, err := database.Conn(ctx, repo.db).NamedExecContext(ctx, q, entries)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving task history: %v", err), slog.Int("task_id", entries[0].TaskID))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreHistoryRepository) GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int, paginate dto.Pagination) (HistoryList, error) {
+	conn := database.Conn(ctx, repo.db)
+
+	q := `SELECT id, task_id, account_id, actor_id, action, field, old_value, new_value, created_at
+		FROM task_history
+		WHERE account_id = $1 AND task_id = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4`
+
+	var entries []HistoryEntry
+	err := conn.SelectContext(ctx, &entries, q, accId, taskId, paginate.PageSize, common.GetOffset(paginate.Page, paginate.PageSize))
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task history: %v", err), slog.Int("task_id", taskId))
+		return HistoryList{}, err
+	}
+
+	q = `SELECT COUNT(id) FROM task_history WHERE account_id = $1 AND task_id = $2`
+	var totalCount int
+	if err := conn.QueryRowContext(ctx, q, accId, taskId).Scan(&totalCount); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task history count: %v", err), slog.Int("task_id", taskId))
+		return HistoryList{}, err
+	}
+
+	return HistoryList{
+		Entries: entries,
+		Pagination: dto.PaginationMetadata{
+			Pagination: paginate,
+			Total:      totalCount,
+		},
+	}, nil
+}
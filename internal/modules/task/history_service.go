@@ -0,0 +1,69 @@
+package task
+
+import (
+	"context"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+func (svc TaskService) GetHistory(ctx context.Context, accId, id int, paginate dto.Pagination) (dto.TaskHistory, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+		return dto.TaskHistory{}, err
+	}
+
+	historyList, err := svc.historyRepo.GetByAccountIDAndTaskID(ctx, accId, id, paginate)
+	if err != nil {
+		return dto.TaskHistory{}, err
+	}
+
+	history := dto.TaskHistory{
+		Entries:    []dto.TaskHistoryEntry{},
+		Pagination: historyList.Pagination,
+	}
+
+	for _, entry := range historyList.Entries {
+		history.Entries = append(history.Entries, dto.TaskHistoryEntry{
+			ID:        entry.ID,
+			ActorID:   entry.ActorID,
+			Action:    entry.Action,
+			Field:     entry.Field,
+			OldValue:  entry.OldValue,
+			NewValue:  entry.NewValue,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	return history, nil
+}
+
+// getTaskWithRelations fetches a task along with its relations,
+// so all of its tracked fields can be compared
+func (svc TaskService) getTaskWithRelations(ctx context.Context, accId, id int) (Task, error) {
+	task, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	tasks := []Task{task}
+	if err := svc.loadRelations(ctx, tasks); err != nil {
+		return Task{}, err
+	}
+
+	return tasks[0], nil
+}
+
+// recordChanges saves the changes from before to after in the task history.
+// It must be called in the same transaction as the change itself.
+func (svc TaskService) recordChanges(ctx context.Context, actorId int, action string, before, after Task) error {
+	return svc.historyRepo.Save(ctx, diffTask(actorId, action, before, after))
+}
+
+// recordAction saves a change of the whole task, such as deleting it
+func (svc TaskService) recordAction(ctx context.Context, actorId int, action string, task Task) error {
+	return svc.historyRepo.Save(ctx, []HistoryEntry{{
+		TaskID:    task.ID,
+		AccountID: task.AccountID,
+		ActorID:   actorId,
+		Action:    action,
+	}})
+}
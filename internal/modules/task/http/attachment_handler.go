@@ -0,0 +1,175 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"mime"
+	stdhttp "net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/modules/task"
+)
+
+// multipartOverhead is the room left in the request body
+// for the multipart envelope around the uploaded file
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	attachmentSvc task.AttachmentService
+	logger        *slog.Logger
+	authMddl      echo.MiddlewareFunc
+	// maxSize is the maximum attachment size in bytes
+	maxSize int64
+}
+
+func NewAttachmentHandler(attachmentSvc task.AttachmentService, logger *slog.Logger, authMddl echo.MiddlewareFunc, maxSize int64) AttachmentHandler {
+	return AttachmentHandler{attachmentSvc: attachmentSvc, logger: logger, authMddl: authMddl, maxSize: maxSize}
+}
+
+func RegisterAttachmentHandler(h AttachmentHandler, router *echo.Echo) {
+	group := router.Group("tasks", h.authMddl)
+	group.POST("/:id/attachments", h.Upload)
+	group.GET("/:id/attachments", h.GetAttachments)
+	group.GET("/:id/attachments/:attachment_id", h.Download)
+	group.DELETE("/:id/attachments/:attachment_id", h.Delete)
+}
+
+// Upload accepts a multipart/form-data body with the file in the file field
+func (h AttachmentHandler) Upload(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	// Larger bodies are rejected before being read in full
+	req := ectx.Request()
+	req.Body = stdhttp.MaxBytesReader(ectx.Response(), req.Body, h.maxSize+multipartOverhead)
+
+	fh, err := ectx.FormFile("file")
+	if err != nil {
+		var errMaxBytes *stdhttp.MaxBytesError
+		if errors.As(err, &errMaxBytes) {
+			return common.ErrorResponse(ectx, task.ErrAttachmentTooLarge)
+		}
+
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	defer file.Close()
+
+	attachment, err := h.attachmentSvc.Upload(ctx, accId, id, task.AttachmentUpload{
+		Filename:    fh.Filename,
+		ContentType: fh.Header.Get(echo.HeaderContentType),
+		Size:        fh.Size,
+		Content:     file,
+	})
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", attachment)
+}
+
+func (h AttachmentHandler) GetAttachments(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	attachments, err := h.attachmentSvc.GetAttachments(ctx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", attachments)
+}
+
+// Download sends the attachment content, supporting range requests
+// and conditional requests on the content hash
+func (h AttachmentHandler) Download(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	attachmentId, err := strconv.Atoi(ectx.Param("attachment_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid attachment number"))
+	}
+
+	attachment, blob, err := h.attachmentSvc.Open(ctx, accId, id, attachmentId)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	defer blob.Close()
+
+	// Attachments are always downloaded rather than rendered,
+	// so uploaded HTML or SVG can not run in the API origin
+	header := ectx.Response().Header()
+	header.Set(echo.HeaderContentType, attachment.ContentType)
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	header.Set(headerETag, `"`+attachment.SHA256+`"`)
+
+	stdhttp.ServeContent(ectx.Response(), ectx.Request(), attachment.Filename, attachment.CreatedAt, blob)
+
+	return nil
+}
+
+func (h AttachmentHandler) Delete(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	attachmentId, err := strconv.Atoi(ectx.Param("attachment_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid attachment number"))
+	}
+
+	if err := h.attachmentSvc.Delete(ctx, accId, id, attachmentId); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+func (h TaskHandler) GetChecklist(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	items, err := h.taskSvc.GetChecklist(ctx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", items)
+}
+
+func (h TaskHandler) AddChecklistItem(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.CreateChecklistItemRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	item, err := h.taskSvc.AddChecklistItem(ctx, accId, id, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", item)
+}
+
+func (h TaskHandler) MoveChecklistItem(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.MoveChecklistItemRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	itemId, err := strconv.Atoi(ectx.Param("item_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid checklist item number"))
+	}
+
+	item, err := h.taskSvc.MoveChecklistItem(ctx, accId, id, itemId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", item)
+}
+
+func (h TaskHandler) ToggleChecklistItem(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	itemId, err := strconv.Atoi(ectx.Param("item_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid checklist item number"))
+	}
+
+	item, err := h.taskSvc.ToggleChecklistItem(ctx, accId, id, itemId)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", item)
+}
+
+func (h TaskHandler) DeleteChecklistItem(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	itemId, err := strconv.Atoi(ectx.Param("item_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid checklist item number"))
+	}
+
+	if err := h.taskSvc.DeleteChecklistItem(ctx, accId, id, itemId); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
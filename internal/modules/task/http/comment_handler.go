@@ -0,0 +1,122 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+func (h TaskHandler) GetComments(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Pagination
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	comments, err := h.taskSvc.GetComments(ctx, accId, id, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", comments)
+}
+
+func (h TaskHandler) AddComment(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.CreateCommentRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	comment, err := h.taskSvc.AddComment(ctx, accId, id, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", comment)
+}
+
+func (h TaskHandler) UpdateComment(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.UpdateCommentRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	commentId, err := strconv.Atoi(ectx.Param("comment_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid comment number"))
+	}
+
+	comment, err := h.taskSvc.UpdateComment(ctx, accId, id, commentId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", comment)
+}
+
+func (h TaskHandler) DeleteComment(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	commentId, err := strconv.Atoi(ectx.Param("comment_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid comment number"))
+	}
+
+	if err := h.taskSvc.DeleteComment(ctx, accId, id, commentId); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/internal/modules/task"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+var (
+	errInvalidIfMatch = common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "If-Match must be a list of entity tags or *",
+	}
+
+	errIfMatchRequired = common.Error{
+		Code:    common.ErrCodePreconditionRequired,
+		Message: "If-Match header is required",
+	}
+)
+
+// taskETag identifies the representation of a task, as "version-hash".
+// The hash covers the fields derived from other data, such as the overdue
+// flag and the comment count, which change without a new version.
+func taskETag(t dto.Task) string {
+	tag := strconv.Itoa(t.Version)
+	if b, err := json.Marshal(t); err == nil {
+		sum := sha256.Sum256(b)
+		tag += "-" + hex.EncodeToString(sum[:8])
+	}
+
+	return `"` + tag + `"`
+}
+
+func setETag(ectx echo.Context, t dto.Task) {
+	ectx.Response().Header().Set(headerETag, taskETag(t))
+}
+
+// ifMatchTags returns the entity tags listed in the If-Match header.
+// Nil is returned if the header is absent or matches any tag.
+func ifMatchTags(ectx echo.Context) ([]string, error) {
+	value := strings.TrimSpace(ectx.Request().Header.Get(headerIfMatch))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		// If-Match uses the strong comparison, so weak tags never match
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			return nil, errInvalidIfMatch
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// requestedVersion returns the task version required by the If-Match header,
+// rejecting the request without the header if the handler requires it.
+// Zero is returned if any version matches.
+func (h TaskHandler) requestedVersion(ctx context.Context, ectx echo.Context, accId, id int) (int, error) {
+	if h.requireIfMatch && ectx.Request().Header.Get(headerIfMatch) == "" {
+		return 0, errIfMatchRequired
+	}
+
+	tags, err := ifMatchTags(ectx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(tags) == 0 {
+		return 0, nil
+	}
+
+	// The version of the matched representation is checked again when
+	// the task is written, in case it changes in the meantime
+	current, err := h.taskSvc.GetByIDInOrOutOfTrash(ctx, accId, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if !slices.Contains(tags, taskETag(current)) {
+		return 0, task.ErrVersionMismatch
+	}
+
+	return current.Version, nil
+}
+
+// noneMatch reports whether the If-None-Match header does not match
+// the task, meaning the client does not have the current representation
+func noneMatch(ectx echo.Context, t dto.Task) bool {
+	value := ectx.Request().Header.Get(headerIfNoneMatch)
+	if value == "" {
+		return true
+	}
+
+	etag := taskETag(t)
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return false
+		}
+	}
+
+	return true
+}
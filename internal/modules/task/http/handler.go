@@ -2,32 +2,64 @@ package http
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
+	stdhttp "net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/tamboto2000/otaqku-tasks/internal/common"
 	"github.com/tamboto2000/otaqku-tasks/internal/dto"
 	"github.com/tamboto2000/otaqku-tasks/internal/modules/task"
+	"github.com/tamboto2000/otaqku-tasks/pkg/jsonpatch"
+)
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
 )
 
 type TaskHandler struct {
 	taskSvc  task.TaskService
 	logger   *slog.Logger
 	authMddl echo.MiddlewareFunc
+	// requireIfMatch rejects changes to a task sent without If-Match
+	requireIfMatch bool
 }
 
-func NewTaskHandler(taskSvc task.TaskService, logger *slog.Logger, authMddl echo.MiddlewareFunc) TaskHandler {
-	return TaskHandler{taskSvc: taskSvc, logger: logger, authMddl: authMddl}
+func NewTaskHandler(taskSvc task.TaskService, logger *slog.Logger, authMddl echo.MiddlewareFunc, requireIfMatch bool) TaskHandler {
+	return TaskHandler{taskSvc: taskSvc, logger: logger, authMddl: authMddl, requireIfMatch: requireIfMatch}
 }
 
 func RegisterTaskHandler(h TaskHandler, router *echo.Echo) {
 	group := router.Group("tasks", h.authMddl)
 	group.POST("", h.CreateTask)
 	group.GET("", h.GetTaskList)
+	group.GET("/trash", h.GetTrash)
+	group.POST("/batch", h.Batch)
 	group.GET("/:id", h.GetByID)
 	group.PUT("/:id", h.Update)
+	group.PATCH("/:id", h.Patch)
 	group.DELETE("/:id", h.Delete)
+	group.GET("/:id/children", h.GetChildren)
+	group.PUT("/:id/parent", h.MoveToParent)
+	group.POST("/:id/move", h.Move)
+	group.GET("/:id/dependencies", h.GetDependencies)
+	group.POST("/:id/dependencies", h.AddDependency)
+	group.DELETE("/:id/dependencies/:blocker_id", h.RemoveDependency)
+	group.GET("/:id/history", h.GetHistory)
+	group.GET("/:id/comments", h.GetComments)
+	group.POST("/:id/comments", h.AddComment)
+	group.PUT("/:id/comments/:comment_id", h.UpdateComment)
+	group.DELETE("/:id/comments/:comment_id", h.DeleteComment)
+	group.GET("/:id/checklist", h.GetChecklist)
+	group.POST("/:id/checklist", h.AddChecklistItem)
+	group.POST("/:id/checklist/:item_id/move", h.MoveChecklistItem)
+	group.POST("/:id/checklist/:item_id/toggle", h.ToggleChecklistItem)
+	group.DELETE("/:id/checklist/:item_id", h.DeleteChecklistItem)
+	group.POST("/:id/restore", h.Restore)
 }
 
 func (h TaskHandler) CreateTask(ectx echo.Context) error {
@@ -43,11 +75,33 @@ func (h TaskHandler) CreateTask(ectx echo.Context) error {
 		return common.InternalServerErrorResponse(ectx, h.logger, err)
 	}
 
-	if err := h.taskSvc.CreateTask(ctx, accId, req); err != nil {
+	task, err := h.taskSvc.CreateTask(ctx, accId, req)
+	if err != nil {
 		return common.ErrorResponse(ectx, err)
 	}
 
-	return common.OKResponse(ectx, "success", nil)
+	return common.OKResponse(ectx, "success", task)
+}
+
+func (h TaskHandler) Batch(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.BatchTaskRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	resp, err := h.taskSvc.Batch(ctx, accId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", resp)
 }
 
 func (h TaskHandler) GetTaskList(ectx echo.Context) error {
@@ -57,12 +111,17 @@ func (h TaskHandler) GetTaskList(ectx echo.Context) error {
 		return common.InvalidQueryParamResponse(ectx, err)
 	}
 
+	var filter dto.TaskFilter
+	if err := ectx.Bind(&filter); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
 	accId, err := common.AccountIDFromEchoCtx(ectx)
 	if err != nil {
 		return common.InternalServerErrorResponse(ectx, h.logger, err)
 	}
 
-	taskList, err := h.taskSvc.GetTaskList(ctx, accId, req)
+	taskList, err := h.taskSvc.GetTaskList(ctx, accId, filter, req)
 	if err != nil {
 		return common.ErrorResponse(ectx, err)
 	}
@@ -89,6 +148,11 @@ func (h TaskHandler) GetByID(ectx echo.Context) error {
 		return common.ErrorResponse(ectx, err)
 	}
 
+	setETag(ectx, task)
+	if !noneMatch(ectx, task) {
+		return ectx.NoContent(stdhttp.StatusNotModified)
+	}
+
 	return common.OKResponse(ectx, "success", task)
 }
 
@@ -106,7 +170,26 @@ func (h TaskHandler) Delete(ectx echo.Context) error {
 		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
 	}
 
-	if err := h.taskSvc.Delete(ctx, accId, id); err != nil {
+	permanent := false
+	if permanentStr := ectx.QueryParam("permanent"); permanentStr != "" {
+		permanent, err = strconv.ParseBool(permanentStr)
+		if err != nil {
+			return common.InvalidQueryParamResponse(ectx, errors.New("invalid permanent flag"))
+		}
+	}
+
+	version, err := h.requestedVersion(ctx, ectx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	if permanent {
+		err = h.taskSvc.DeletePermanently(ctx, accId, id, version)
+	} else {
+		err = h.taskSvc.Delete(ctx, accId, id, version)
+	}
+
+	if err != nil {
 		return common.ErrorResponse(ectx, err)
 	}
 
@@ -133,8 +216,298 @@ func (h TaskHandler) Update(ectx echo.Context) error {
 	}
 
 	req.ID = id
+	req.Scope = ectx.QueryParam("scope")
+	req.Version, err = h.requestedVersion(ctx, ectx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	task, err := h.taskSvc.Update(ctx, accId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	setETag(ectx, task)
+
+	return common.OKResponse(ectx, "success", task)
+}
+
+// Patch accepts a JSON Merge Patch (RFC 7396) document, or a JSON Patch
+// (RFC 6902) document when sent as application/json-patch+json
+func (h TaskHandler) Patch(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	body, err := io.ReadAll(ectx.Request().Body)
+	if err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	var patch jsonpatch.Patch
+	mediaType, _, _ := mime.ParseMediaType(ectx.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case mimeMergePatch, echo.MIMEApplicationJSON:
+		patch = jsonpatch.MergePatch(body)
+
+	case mimeJSONPatch:
+		patch, err = jsonpatch.DecodeJSONPatch(body)
+		if err != nil {
+			return common.InvalidReqBodyResponse(ectx, err)
+		}
+
+	default:
+		return common.UnsupportedMediaTypeResponse(ectx, fmt.Errorf("content type must be %s or %s", mimeMergePatch, mimeJSONPatch))
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	version, err := h.requestedVersion(ctx, ectx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	task, err := h.taskSvc.Patch(ctx, accId, id, version, ectx.QueryParam("scope"), patch)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	setETag(ectx, task)
+
+	return common.OKResponse(ectx, "success", task)
+}
+
+func (h TaskHandler) Move(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.MoveTaskRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	task, err := h.taskSvc.Move(ctx, accId, id, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	setETag(ectx, task)
+
+	return common.OKResponse(ectx, "success", task)
+}
+
+func (h TaskHandler) GetChildren(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Pagination
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	var filter dto.TaskFilter
+	if err := ectx.Bind(&filter); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	taskList, err := h.taskSvc.GetChildren(ctx, accId, id, filter, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", taskList)
+}
+
+func (h TaskHandler) MoveToParent(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.MoveTaskParentRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	if err := h.taskSvc.MoveToParent(ctx, accId, id, req); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
+
+func (h TaskHandler) GetDependencies(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	deps, err := h.taskSvc.GetDependencies(ctx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", deps)
+}
+
+func (h TaskHandler) AddDependency(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.AddDependencyRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	if err := h.taskSvc.AddDependency(ctx, accId, id, req); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
+
+func (h TaskHandler) RemoveDependency(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	blockerId, err := strconv.Atoi(ectx.Param("blocker_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid blocker task number"))
+	}
+
+	if err := h.taskSvc.RemoveDependency(ctx, accId, id, blockerId); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
+
+func (h TaskHandler) GetHistory(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Pagination
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	history, err := h.taskSvc.GetHistory(ctx, accId, id, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", history)
+}
+
+func (h TaskHandler) GetTrash(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Pagination
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	taskList, err := h.taskSvc.GetTrash(ctx, accId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", taskList)
+}
+
+func (h TaskHandler) Restore(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
 
-	if err := h.taskSvc.Update(ctx, accId, req); err != nil {
+	if err := h.taskSvc.Restore(ctx, accId, id); err != nil {
 		return common.ErrorResponse(ectx, err)
 	}
 
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/internal/modules/task"
+)
+
+type TagHandler struct {
+	tagSvc   task.TagService
+	logger   *slog.Logger
+	authMddl echo.MiddlewareFunc
+}
+
+func NewTagHandler(tagSvc task.TagService, logger *slog.Logger, authMddl echo.MiddlewareFunc) TagHandler {
+	return TagHandler{tagSvc: tagSvc, logger: logger, authMddl: authMddl}
+}
+
+func RegisterTagHandler(h TagHandler, router *echo.Echo) {
+	group := router.Group("tags", h.authMddl)
+	group.POST("", h.CreateTag)
+	group.GET("", h.GetTagList)
+	group.GET("/:id", h.GetByID)
+	group.PUT("/:id", h.Update)
+	group.DELETE("/:id", h.Delete)
+}
+
+func (h TagHandler) CreateTag(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.CreateTagRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	tag, err := h.tagSvc.CreateTag(ctx, accId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", tag)
+}
+
+func (h TagHandler) GetTagList(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	tagList, err := h.tagSvc.GetTagList(ctx, accId)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", tagList)
+}
+
+func (h TagHandler) GetByID(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := strconv.Atoi(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid tag number"))
+	}
+
+	tag, err := h.tagSvc.GetByID(ctx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", tag)
+}
+
+func (h TagHandler) Update(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Tag
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := strconv.Atoi(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid tag number"))
+	}
+
+	req.ID = id
+
+	tag, err := h.tagSvc.Update(ctx, accId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", tag)
+}
+
+func (h TagHandler) Delete(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	id, err := strconv.Atoi(ectx.Param("id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid tag number"))
+	}
+
+	if err := h.tagSvc.Delete(ctx, accId, id); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
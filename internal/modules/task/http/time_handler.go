@@ -0,0 +1,184 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/internal/modules/task"
+)
+
+type TimeHandler struct {
+	timeSvc  task.TimeService
+	logger   *slog.Logger
+	authMddl echo.MiddlewareFunc
+}
+
+func NewTimeHandler(timeSvc task.TimeService, logger *slog.Logger, authMddl echo.MiddlewareFunc) TimeHandler {
+	return TimeHandler{timeSvc: timeSvc, logger: logger, authMddl: authMddl}
+}
+
+func RegisterTimeHandler(h TimeHandler, router *echo.Echo) {
+	group := router.Group("tasks", h.authMddl)
+	group.GET("/:id/time-entries", h.GetTimeEntries)
+	group.POST("/:id/time-entries", h.AddTimeEntry)
+	group.DELETE("/:id/time-entries/:entry_id", h.DeleteTimeEntry)
+	group.POST("/:id/timer/start", h.StartTimer)
+	group.POST("/:id/timer/stop", h.StopTimer)
+
+	reports := router.Group("reports", h.authMddl)
+	reports.GET("/time", h.GetTimeReport)
+}
+
+func (h TimeHandler) GetTimeEntries(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.Pagination
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	entries, err := h.timeSvc.GetTimeEntries(ctx, accId, id, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", entries)
+}
+
+func (h TimeHandler) AddTimeEntry(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.CreateTimeEntryRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	entry, err := h.timeSvc.AddTimeEntry(ctx, accId, id, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", entry)
+}
+
+func (h TimeHandler) DeleteTimeEntry(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	entryId, err := strconv.Atoi(ectx.Param("entry_id"))
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid time entry number"))
+	}
+
+	if err := h.timeSvc.DeleteTimeEntry(ctx, accId, id, entryId); err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", nil)
+}
+
+func (h TimeHandler) StartTimer(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.StartTimerRequest
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidReqBodyResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	timer, err := h.timeSvc.StartTimer(ctx, accId, id, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", timer)
+}
+
+func (h TimeHandler) StopTimer(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	idStr := ectx.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return common.InvalidQueryParamResponse(ectx, errors.New("invalid task number"))
+	}
+
+	entry, err := h.timeSvc.StopTimer(ctx, accId, id)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", entry)
+}
+
+func (h TimeHandler) GetTimeReport(ectx echo.Context) error {
+	ctx := ectx.Request().Context()
+
+	var req dto.TimeReportQuery
+	if err := ectx.Bind(&req); err != nil {
+		return common.InvalidQueryParamResponse(ectx, err)
+	}
+
+	accId, err := common.AccountIDFromEchoCtx(ectx)
+	if err != nil {
+		return common.InternalServerErrorResponse(ectx, h.logger, err)
+	}
+
+	report, err := h.timeSvc.GetTimeReport(ctx, accId, req)
+	if err != nil {
+		return common.ErrorResponse(ectx, err)
+	}
+
+	return common.OKResponse(ectx, "success", report)
+}
@@ -0,0 +1,71 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+)
+
+func (repo PostgreTaskRepository) GetLastRank(ctx context.Context, accId int) (string, error) {
+	// Deleted tasks are included, so restoring them does not tie with other tasks
+	q := `SELECT COALESCE(MAX(rank), '') FROM tasks WHERE account_id = $1`
+
+	var rank string
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, accId).Scan(&rank); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching last task rank: %v", err), slog.Int("account_id", accId))
+		return "", err
+	}
+
+	return rank, nil
+}
+
+func (repo PostgreTaskRepository) GetLastRankByStatus(ctx context.Context, accId, id int, status string) (string, error) {
+	q := `SELECT COALESCE(MAX(rank), '') FROM tasks WHERE account_id = $1 AND id <> $2 AND status = $3 AND deleted_at IS NULL`
+
+	var rank string
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, accId, id, status).Scan(&rank); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching last task rank of status: %v", err), slog.Int("account_id", accId))
+		return "", err
+	}
+
+	return rank, nil
+}
+
+func (repo PostgreTaskRepository) GetAdjacentRank(ctx context.Context, accId, id int, rank string, status *string, before bool) (string, error) {
+	cmp, dir := ">", "ASC"
+	if before {
+		cmp, dir = "<", "DESC"
+	}
+
+	where := `account_id = ? AND id <> ? AND deleted_at IS NULL AND rank ` + cmp + ` ?`
+	args := []any{accId, id, rank}
+	if status != nil {
+		where += ` AND status = ?`
+		args = append(args, *status)
+	}
+
+	q := repo.db.Rebind(`SELECT COALESCE((SELECT rank FROM tasks WHERE ` + where + ` ORDER BY rank ` + dir + ` LIMIT 1), '')`)
+
+	var adjacent string
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, args...).Scan(&adjacent); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching adjacent task rank: %v", err), slog.Int("id", id))
+		return "", err
+	}
+
+	return adjacent, nil
+}
+
+func (repo PostgreTaskRepository) UpdateRankByAccountIDAndID(ctx context.Context, accId, id int, rank string) error {
+	q := `UPDATE tasks SET rank = $1, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE account_id = $2 AND id = $3 AND deleted_at IS NULL`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, rank, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating task rank: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
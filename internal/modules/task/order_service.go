@@ -0,0 +1,180 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/pkg/rank"
+)
+
+// validateMoveAnchors checks the before_id and after_id of a request moving
+// the thing with the id, which is named in the error messages
+func validateMoveAnchors(id int, beforeId, afterId *int, thing string) []common.FieldError {
+	var fields []common.FieldError
+	if beforeId != nil && afterId != nil {
+		fields = append(fields, common.FieldError{
+			Name:     "before_id",
+			Messages: []string{"only one of before_id and after_id can be set"},
+		})
+	}
+
+	if (beforeId != nil && *beforeId == id) || (afterId != nil && *afterId == id) {
+		fields = append(fields, common.FieldError{
+			Name:     "before_id",
+			Messages: []string{thing + " can not be moved next to itself"},
+		})
+	}
+
+	return fields
+}
+
+func validateMoveRequest(id int, req dto.MoveTaskRequest) error {
+	errValidation := common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid input",
+		Fields:  validateMoveAnchors(id, req.BeforeID, req.AfterID, "a task"),
+	}
+
+	if req.Status != nil && !isValidStatus(*req.Status) {
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "status",
+			Messages: []string{"invalid status, valid statuses are: todo, in_progress, blocked, done, and abandoned"},
+		})
+	}
+
+	if len(errValidation.Fields) != 0 {
+		return errValidation
+	}
+
+	return nil
+}
+
+// Move places a task right before or after another task in the user
+// defined order, or at the end of its status if neither is given. With a status,
+// the task is moved into that status and placed among its tasks.
+func (svc TaskService) Move(ctx context.Context, accId, id int, req dto.MoveTaskRequest) (dto.Task, error) {
+	if err := validateMoveRequest(id, req); err != nil {
+		return dto.Task{}, err
+	}
+
+	var moved Task
+	err := svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		task, err := svc.getTaskWithRelations(ctx, accId, id)
+		if err != nil {
+			return err
+		}
+
+		// Changing the status goes through the same checks as an update
+		if req.Status != nil && *req.Status != task.Status {
+			updateReq := taskToTaskDTO(task, time.Now())
+			updateReq.Status = *req.Status
+			if _, err := svc.Update(ctx, accId, updateReq); err != nil {
+				return err
+			}
+		}
+
+		var lo, hi string
+		switch {
+		case req.AfterID != nil:
+			anchor, err := svc.getMoveAnchor(ctx, accId, *req.AfterID, "after_id", req.Status)
+			if err != nil {
+				return err
+			}
+
+			lo = anchor.Rank
+			hi, err = svc.taskRepo.GetAdjacentRank(ctx, accId, id, anchor.Rank, req.Status, false)
+			if err != nil {
+				return err
+			}
+
+		case req.BeforeID != nil:
+			anchor, err := svc.getMoveAnchor(ctx, accId, *req.BeforeID, "before_id", req.Status)
+			if err != nil {
+				return err
+			}
+
+			hi = anchor.Rank
+			lo, err = svc.taskRepo.GetAdjacentRank(ctx, accId, id, anchor.Rank, req.Status, true)
+			if err != nil {
+				return err
+			}
+
+		default:
+			status := task.Status
+			if req.Status != nil {
+				status = *req.Status
+			}
+
+			// The task goes after the last task of its status, before whatever
+			// task of another status follows it
+			lo, err = svc.taskRepo.GetLastRankByStatus(ctx, accId, id, status)
+			if err != nil {
+				return err
+			}
+
+			// Alone in its status, the task goes at the end of all tasks
+			if lo == "" {
+				lo, err = svc.taskRepo.GetLastRank(ctx, accId)
+				if err != nil {
+					return err
+				}
+
+				break
+			}
+
+			hi, err = svc.taskRepo.GetAdjacentRank(ctx, accId, id, lo, nil, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		newRank, err := rank.Between(lo, hi)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.taskRepo.UpdateRankByAccountIDAndID(ctx, accId, id, newRank); err != nil {
+			return err
+		}
+
+		moved, err = svc.getTaskWithRelations(ctx, accId, id)
+
+		return err
+	})
+
+	if err != nil {
+		return dto.Task{}, err
+	}
+
+	return taskToTaskDTO(moved, time.Now()), nil
+}
+
+// getMoveAnchor fetches the task another task is moved next to,
+// which must have the status if one is given
+func (svc TaskService) getMoveAnchor(ctx context.Context, accId, anchorId int, field string, status *string) (Task, error) {
+	anchor, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, anchorId)
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			return Task{}, common.Error{
+				Code:    common.ErrCodeInputValidation,
+				Message: "Invalid input",
+				Fields:  []common.FieldError{{Name: field, Messages: []string{"task does not exist"}}},
+			}
+		}
+
+		return Task{}, err
+	}
+
+	if status != nil && anchor.Status != *status {
+		return Task{}, common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  []common.FieldError{{Name: field, Messages: []string{"task is not in the given status"}}},
+		}
+	}
+
+	return anchor, nil
+}
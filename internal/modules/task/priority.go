@@ -0,0 +1,48 @@
+package task
+
+import (
+	"strings"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+)
+
+// Priority is stored as a number so tasks can be sorted by importance
+type Priority int
+
+// Task priorities
+const (
+	PriorityLow Priority = iota + 1
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityMedium: "medium",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+// parsePriority parses the name of a priority.
+// An empty name is the medium priority.
+func parsePriority(name string) (Priority, *common.FieldError) {
+	if name == "" {
+		return PriorityMedium, nil
+	}
+
+	for p, pName := range priorityNames {
+		if pName == strings.ToLower(name) {
+			return p, nil
+		}
+	}
+
+	return 0, &common.FieldError{
+		Name:     "priority",
+		Messages: []string{"invalid priority, valid priorities are: low, medium, high, and urgent"},
+	}
+}
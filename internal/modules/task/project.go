@@ -0,0 +1,43 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+)
+
+// ProjectChecker tells whether tasks can be put in a project,
+// it is implemented by the project module
+type ProjectChecker interface {
+	IsActiveByAccountIDAndID(ctx context.Context, accId, id int) (bool, error)
+}
+
+func (repo PostgreTaskRepository) DeleteByAccountIDAndProjectID(ctx context.Context, accId, projectId int) ([]int, error) {
+	q := `UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE account_id = $1 AND project_id = $2 AND deleted_at IS NULL
+		RETURNING id`
+
+	var ids []int
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &ids, q, accId, projectId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting tasks of a project: %v", err), slog.Int("project_id", projectId))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (repo PostgreTaskRepository) ClearProjectByAccountIDAndProjectID(ctx context.Context, accId, projectId int) ([]int, error) {
+	q := `UPDATE tasks SET project_id = NULL, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE account_id = $1 AND project_id = $2 AND deleted_at IS NULL
+		RETURNING id`
+
+	var ids []int
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &ids, q, accId, projectId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on moving tasks of a project to the inbox: %v", err), slog.Int("project_id", projectId))
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,80 @@
+package task
+
+import (
+	"context"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+)
+
+var ErrProjectNotFound = common.Error{
+	Code:    common.ErrCodeInputValidation,
+	Message: "Invalid input",
+	Fields: []common.FieldError{
+		{Name: "project_id", Messages: []string{"project does not exist or is archived"}},
+	},
+}
+
+// checkProject makes sure tasks can be put in the project
+func (svc TaskService) checkProject(ctx context.Context, accId, projectId int) error {
+	active, err := svc.projects.IsActiveByAccountIDAndID(ctx, accId, projectId)
+	if err != nil {
+		return err
+	}
+
+	if !active {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
+// DeleteByProject moves the tasks of a project to the trash
+func (svc TaskService) DeleteByProject(ctx context.Context, accId, projectId int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		ids, err := svc.taskRepo.DeleteByAccountIDAndProjectID(ctx, accId, projectId)
+		if err != nil {
+			return err
+		}
+
+		entries := make([]HistoryEntry, len(ids))
+		for i, id := range ids {
+			entries[i] = HistoryEntry{
+				TaskID:    id,
+				AccountID: accId,
+				ActorID:   accId,
+				Action:    HistoryActionDelete,
+			}
+		}
+
+		if err := svc.historyRepo.Save(ctx, entries); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := svc.releaseBlocked(ctx, accId, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// MoveProjectTasksToInbox takes the tasks of a project out of it
+func (svc TaskService) MoveProjectTasksToInbox(ctx context.Context, accId, projectId int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		ids, err := svc.taskRepo.ClearProjectByAccountIDAndProjectID(ctx, accId, projectId)
+		if err != nil {
+			return err
+		}
+
+		var entries []HistoryEntry
+		for _, id := range ids {
+			before := Task{ID: id, AccountID: accId, ProjectID: &projectId}
+			after := Task{ID: id, AccountID: accId}
+			entries = append(entries, diffTask(accId, HistoryActionUpdate, before, after)...)
+		}
+
+		return svc.historyRepo.Save(ctx, entries)
+	})
+}
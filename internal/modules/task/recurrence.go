@@ -0,0 +1,295 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/pkg/rrule"
+	"github.com/vinovest/sqlx"
+)
+
+// Series edit scopes, they tell whether editing an occurrence
+// of a recurring task also changes the occurrences after it
+const (
+	SeriesScopeThis   = "this"
+	SeriesScopeFuture = "future"
+)
+
+func validateSeriesScope(scope string) *common.FieldError {
+	switch scope {
+	case "", SeriesScopeThis, SeriesScopeFuture:
+		return nil
+	}
+
+	return &common.FieldError{
+		Name:     "scope",
+		Messages: []string{"invalid scope, valid scopes are: this and future"},
+	}
+}
+
+// normalizeRecurrence validates an RRULE and returns it in its canonical form.
+// Recurring tasks need a due date, as occurrences are scheduled from it.
+func normalizeRecurrence(recurrence string, dueAt *time.Time) (string, *common.FieldError) {
+	if recurrence == "" {
+		return "", nil
+	}
+
+	rule, err := rrule.Parse(recurrence)
+	if err != nil {
+		return "", &common.FieldError{Name: "recurrence", Messages: []string{err.Error()}}
+	}
+
+	if dueAt == nil {
+		return "", &common.FieldError{Name: "recurrence", Messages: []string{"recurring tasks must have a due_at"}}
+	}
+
+	return rule.String(), nil
+}
+
+// seriesTags are the tag names of a series, stored as a JSON array
+type seriesTags []string
+
+func (tags *seriesTags) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, tags)
+	case string:
+		return json.Unmarshal([]byte(v), tags)
+	}
+
+	return fmt.Errorf("can not scan %T into series tags", src)
+}
+
+func (tags seriesTags) Value() (driver.Value, error) {
+	if tags == nil {
+		return "[]", nil
+	}
+
+	b, err := json.Marshal([]string(tags))
+	return string(b), err
+}
+
+// Series is the recurrence of a recurring task. Each occurrence is a task of
+// its own, the next one is created from the series when the current one is done.
+type Series struct {
+	ID        int    `db:"id"`
+	AccountID int    `db:"account_id"`
+	Rule      string `db:"rule"`
+	// StartsAt is the due date of the first occurrence, the rule is counted from it
+	StartsAt    time.Time  `db:"starts_at"`
+	ProjectID   *int       `db:"project_id"`
+	Title       string     `db:"title"`
+	Description string     `db:"description"`
+	Priority    Priority   `db:"priority"`
+	Tags        seriesTags `db:"tags"`
+	// StartOffset is how many seconds the occurrences start before they are due,
+	// nil if they have no start date
+	StartOffset *int64    `db:"start_offset"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// newSeries creates a series from a recurring task, which becomes its first occurrence
+func newSeries(task Task) Series {
+	series := Series{
+		AccountID:   task.AccountID,
+		Rule:        task.Recurrence,
+		StartsAt:    *task.DueAt,
+		ProjectID:   task.ProjectID,
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Tags:        task.Tags,
+	}
+
+	if task.StartAt != nil {
+		offset := int64(task.DueAt.Sub(*task.StartAt) / time.Second)
+		series.StartOffset = &offset
+	}
+
+	return series
+}
+
+// nextOccurrence returns the occurrence following task, false if the series has ended.
+// Occurrences follow the schedule of the rule, even if task was done late.
+func (series Series) nextOccurrence(task Task) (Task, bool, error) {
+	rule, err := rrule.Parse(series.Rule)
+	if err != nil {
+		return Task{}, false, err
+	}
+
+	after := series.StartsAt
+	if task.DueAt != nil && task.DueAt.After(after) {
+		after = *task.DueAt
+	}
+
+	dueAt, ok := rule.Next(series.StartsAt, after)
+	if !ok {
+		return Task{}, false, nil
+	}
+
+	next := Task{
+		AccountID:   series.AccountID,
+		ParentID:    task.ParentID,
+		ProjectID:   series.ProjectID,
+		SeriesID:    &series.ID,
+		Occurrence:  task.Occurrence + 1,
+		Title:       series.Title,
+		Description: series.Description,
+		Status:      StatusTODO,
+		Priority:    series.Priority,
+		DueAt:       toUTC(&dueAt),
+		Estimate:    task.Estimate,
+		Tags:        series.Tags,
+		Recurrence:  series.Rule,
+	}
+
+	if series.StartOffset != nil {
+		startAt := dueAt.Add(-time.Duration(*series.StartOffset) * time.Second)
+		next.StartAt = toUTC(&startAt)
+	}
+
+	return next, true, nil
+}
+
+type SeriesRepository interface {
+	Save(ctx context.Context, series Series) (int, error)
+	GetByAccountIDAndID(ctx context.Context, accId, id int) (Series, error)
+	// GetRulesByIDs returns the rules of the series keyed by series ID
+	GetRulesByIDs(ctx context.Context, ids []int) (map[int]string, error)
+	UpdateByAccountIDAndID(ctx context.Context, series Series) error
+	DeleteByAccountIDAndID(ctx context.Context, accId, id int) error
+}
+
+type PostgreSeriesRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreSeriesRepository(db *sqlx.DB, logger *slog.Logger) PostgreSeriesRepository {
+	return PostgreSeriesRepository{db: db, logger: logger}
+}
+
+func (repo PostgreSeriesRepository) Save(ctx context.Context, series Series) (int, error) {
+	q := `INSERT INTO task_series (account_id, rule, starts_at, project_id, title, description, priority, tags, start_offset)
+		VALUES (:account_id, :rule, :starts_at, :project_id, :title, :description, :priority, :tags, :start_offset)
+		RETURNING id`
+
+	conn := database.Conn(ctx, repo.db)
+	q, args, err := conn.BindNamed(q, series)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on binding task series insert query: %v", err))
+		return 0, err
+	}
+
+	var id int
+	if err := conn.QueryRowxContext(ctx, q, args...).Scan(&id); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving task series to database: %v", err), slog.Int("account_id", series.AccountID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreSeriesRepository) GetByAccountIDAndID(ctx context.Context, accId, id int) (Series, error) {
+	q := `SELECT id, account_id, rule, starts_at, project_id, title, description, priority, tags, start_offset, created_at, updated_at
+		FROM task_series WHERE account_id = $1 AND id = $2`
+
+	var series Series
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &series, q, accId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Series{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a task series: %v", err), slog.Int("account_id", accId), slog.Int("id", id))
+		return Series{}, err
+	}
+
+	return series, nil
+}
+
+func (repo PostgreSeriesRepository) GetRulesByIDs(ctx context.Context, ids []int) (map[int]string, error) {
+	rules := make(map[int]string)
+	if len(ids) == 0 {
+		return rules, nil
+	}
+
+	q := `SELECT id, rule FROM task_series WHERE id = ANY($1)`
+
+	rows, err := database.Conn(ctx, repo.db).QueryxContext(ctx, q, ids)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task series rules: %v", err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var rule string
+		if err := rows.Scan(&id, &rule); err != nil {
+			repo.logger.Error(fmt.Sprintf("error on scanning task series rule: %v", err))
+			return nil, err
+		}
+
+		rules[id] = rule
+	}
+
+	return rules, rows.Err()
+}
+
+func (repo PostgreSeriesRepository) UpdateByAccountIDAndID(ctx context.Context, series Series) error {
+	q := `UPDATE task_series
+SET
+    rule         = :rule,
+    starts_at    = :starts_at,
+    project_id   = :project_id,
+    title        = :title,
+    description  = :description,
+    priority     = :priority,
+    tags         = :tags,
+    start_offset = :start_offset,
+    updated_at   = CURRENT_TIMESTAMP
+WHERE account_id = :account_id
+  AND id = :id`
+
+	res, err := database.Conn(ctx, repo.db).NamedExecContext(ctx, q, series)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating a task series: %v", err), slog.Int("id", series.ID))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreSeriesRepository) DeleteByAccountIDAndID(ctx context.Context, accId, id int) error {
+	q := `DELETE FROM task_series WHERE account_id = $1 AND id = $2`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting a task series: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreTaskRepository) IsOccurrenceExists(ctx context.Context, seriesId, occurrence int) (bool, error) {
+	q := `SELECT EXISTS (SELECT 1 FROM tasks WHERE series_id = $1 AND occurrence = $2)`
+
+	var exists bool
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, seriesId, occurrence).Scan(&exists); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on checking task occurrence: %v", err), slog.Int("series_id", seriesId))
+		return false, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,91 @@
+package task
+
+import (
+	"context"
+)
+
+// applyRecurrence saves the recurrence changes of an update of current to task.
+// Editing an occurrence only changes the occurrence itself, unless the scope is
+// future, in which case the changes also apply to the occurrences after it.
+func (svc TaskService) applyRecurrence(ctx context.Context, accId int, current Task, task *Task, scope string) error {
+	task.SeriesID, task.Occurrence = current.SeriesID, current.Occurrence
+
+	// The task becomes the first occurrence of a new series
+	if current.SeriesID == nil {
+		if task.Recurrence == "" {
+			return nil
+		}
+
+		seriesId, err := svc.seriesRepo.Save(ctx, newSeries(*task))
+		if err != nil {
+			return err
+		}
+
+		task.SeriesID, task.Occurrence = &seriesId, 1
+
+		return nil
+	}
+
+	// The recurrence belongs to the series, not to a single occurrence
+	if scope != SeriesScopeFuture {
+		task.Recurrence = current.Recurrence
+		return nil
+	}
+
+	// Removing the recurrence ends the series with this occurrence
+	if task.Recurrence == "" {
+		task.SeriesID, task.Occurrence = nil, 0
+		return svc.seriesRepo.DeleteByAccountIDAndID(ctx, accId, *current.SeriesID)
+	}
+
+	series, err := svc.seriesRepo.GetByAccountIDAndID(ctx, accId, *current.SeriesID)
+	if err != nil {
+		return err
+	}
+
+	updated := newSeries(*task)
+	updated.ID = series.ID
+
+	// A new rule is counted from this occurrence, otherwise the schedule is kept
+	if updated.Rule == series.Rule {
+		updated.StartsAt = series.StartsAt
+	}
+
+	return svc.seriesRepo.UpdateByAccountIDAndID(ctx, updated)
+}
+
+// createNextOccurrence creates the occurrence following a recurring task that is done.
+// Nothing is created if the series has ended, or if the next occurrence already
+// exists because the task was done before.
+func (svc TaskService) createNextOccurrence(ctx context.Context, accId int, task Task) error {
+	series, err := svc.seriesRepo.GetByAccountIDAndID(ctx, accId, *task.SeriesID)
+	if err != nil {
+		return err
+	}
+
+	next, ok, err := series.nextOccurrence(task)
+	if err != nil || !ok {
+		return err
+	}
+
+	exists, err := svc.taskRepo.IsOccurrenceExists(ctx, series.ID, next.Occurrence)
+	if err != nil || exists {
+		return err
+	}
+
+	// Occurrences are not created in archived projects
+	if next.ProjectID != nil {
+		active, err := svc.projects.IsActiveByAccountIDAndID(ctx, accId, *next.ProjectID)
+		if err != nil {
+			return err
+		}
+
+		if !active {
+			next.ProjectID = nil
+		}
+	}
+
+	_, err = svc.insertTask(ctx, accId, next)
+
+	return err
+}
@@ -0,0 +1,83 @@
+package task
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+)
+
+// TransitionTable maps a status to the statuses a task can move to from it.
+// Keeping the same status is always allowed.
+type TransitionTable map[string][]string
+
+// DefaultTransitions is used when the deployment doesn't configure its own
+var DefaultTransitions = TransitionTable{
+	StatusTODO:       {StatusInProgress, StatusBlocked, StatusDone, StatusAbandoned},
+	StatusInProgress: {StatusTODO, StatusBlocked, StatusDone, StatusAbandoned},
+	StatusBlocked:    {StatusTODO, StatusInProgress, StatusAbandoned},
+	StatusDone:       {StatusTODO, StatusInProgress},
+	StatusAbandoned:  {StatusTODO},
+}
+
+// NewTransitionTable validates the configured transitions.
+// DefaultTransitions is returned if there is none.
+func NewTransitionTable(transitions map[string][]string) (TransitionTable, error) {
+	if len(transitions) == 0 {
+		return DefaultTransitions, nil
+	}
+
+	for from, tos := range transitions {
+		if !isValidStatus(from) {
+			return nil, fmt.Errorf("invalid status transition: unknown status %q", from)
+		}
+
+		for _, to := range tos {
+			if !isValidStatus(to) {
+				return nil, fmt.Errorf("invalid status transition from %s: unknown status %q", from, to)
+			}
+		}
+	}
+
+	return TransitionTable(transitions), nil
+}
+
+// Allowed returns the statuses a task can move to from the status
+func (table TransitionTable) Allowed(from string) []string {
+	return table[from]
+}
+
+type StatusTransitionDetails struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Allowed []string `json:"allowed"`
+}
+
+// Validate returns an error listing the allowed next statuses
+// if a task can not move from one status to the other
+func (table TransitionTable) Validate(from, to string) error {
+	if from == to {
+		return nil
+	}
+
+	allowed := table.Allowed(from)
+	if slices.Contains(allowed, to) {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Task status can not be changed from %s to %s", from, to)
+	if len(allowed) != 0 {
+		msg += ", allowed next statuses are: " + strings.Join(allowed, ", ")
+	}
+
+	return common.Error{
+		Code:    common.ErrCodeInvalidStatusTransition,
+		Message: msg,
+		Details: StatusTransitionDetails{
+			From:    from,
+			To:      to,
+			Allowed: append([]string{}, allowed...),
+		},
+	}
+}
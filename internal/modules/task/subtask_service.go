@@ -0,0 +1,98 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+var (
+	ErrParentNotFound = common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid input",
+		Fields: []common.FieldError{
+			{Name: "parent_id", Messages: []string{"parent task does not exist"}},
+		},
+	}
+	ErrParentCycle = common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid input",
+		Fields: []common.FieldError{
+			{Name: "parent_id", Messages: []string{"a task can not be moved under itself or its own subtasks"}},
+		},
+	}
+)
+
+// checkParent makes sure the parent task exists and belongs to the account
+func (svc TaskService) checkParent(ctx context.Context, accId, parentId int) error {
+	_, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, parentId)
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			return ErrParentNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// GetChildren lists the direct subtasks of a task
+func (svc TaskService) GetChildren(ctx context.Context, accId, id int, filter dto.TaskFilter, paginate dto.Pagination) (dto.TaskList, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+		return dto.TaskList{}, err
+	}
+
+	filter.ParentID = &id
+
+	return svc.GetTaskList(ctx, accId, filter, paginate)
+}
+
+// MoveToParent moves a task under a new parent,
+// or makes it a top level task if the parent is nil
+func (svc TaskService) MoveToParent(ctx context.Context, accId, id int, req dto.MoveTaskParentRequest) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		task, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id)
+		if err != nil {
+			return err
+		}
+
+		if req.ParentID != nil {
+			if *req.ParentID == id {
+				return ErrParentCycle
+			}
+
+			// Concurrent moves could each pass the cycle check below
+			// and make a cycle together
+			if err := svc.taskRepo.LockHierarchyByAccountID(ctx, accId); err != nil {
+				return err
+			}
+
+			if err := svc.checkParent(ctx, accId, *req.ParentID); err != nil {
+				return err
+			}
+
+			// The task can not be moved under its own descendant
+			ancestorIds, err := svc.taskRepo.GetAncestorIDs(ctx, *req.ParentID)
+			if err != nil {
+				return err
+			}
+
+			if slices.Contains(ancestorIds, id) {
+				return ErrParentCycle
+			}
+		}
+
+		if err := svc.taskRepo.UpdateParentByAccountIDAndID(ctx, accId, id, req.ParentID); err != nil {
+			return err
+		}
+
+		moved := task
+		moved.ParentID = req.ParentID
+
+		return svc.recordChanges(ctx, accId, HistoryActionUpdate, task, moved)
+	})
+}
@@ -0,0 +1,280 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/vinovest/sqlx"
+)
+
+// Tag filter modes
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+type Tag struct {
+	ID        int       `db:"id"`
+	AccountID int       `db:"account_id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// normalizeTagName trims and lowercases name,
+// so "Backend" and "backend " refer to the same tag
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func validateTagName(name string) []string {
+	var msgs []string
+	if len(name) == 0 {
+		msgs = append(msgs, "tag name can not be empty")
+	}
+
+	if len(name) > 50 {
+		msgs = append(msgs, "tag name can not be longer than 50 characters")
+	}
+
+	return msgs
+}
+
+// normalizeTagNames normalizes and deduplicates names.
+// A nil slice is kept as nil, as it means the tags are left untouched.
+func normalizeTagNames(names []string) ([]string, *common.FieldError) {
+	if names == nil {
+		return nil, nil
+	}
+
+	errTags := common.FieldError{Name: "tags"}
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name = normalizeTagName(name)
+		errTags.Messages = append(errTags.Messages, validateTagName(name)...)
+		if !slices.Contains(normalized, name) {
+			normalized = append(normalized, name)
+		}
+	}
+
+	if len(errTags.Messages) != 0 {
+		return nil, &errTags
+	}
+
+	return normalized, nil
+}
+
+func NewTag(accId int, req dto.CreateTagRequest) (Tag, error) {
+	name := normalizeTagName(req.Name)
+	if msgs := validateTagName(name); len(msgs) != 0 {
+		return Tag{}, common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  []common.FieldError{{Name: "name", Messages: msgs}},
+		}
+	}
+
+	return Tag{
+		AccountID: accId,
+		Name:      name,
+	}, nil
+}
+
+func ValidateTagForUpdate(accId int, req dto.Tag) (Tag, error) {
+	tag, err := NewTag(accId, dto.CreateTagRequest{Name: req.Name})
+	if err != nil {
+		return Tag{}, err
+	}
+
+	tag.ID = req.ID
+
+	return tag, nil
+}
+
+type TagRepository interface {
+	Save(ctx context.Context, tag Tag) (int, error)
+	GetByAccountID(ctx context.Context, accId int) ([]Tag, error)
+	GetByAccountIDAndID(ctx context.Context, accId, id int) (Tag, error)
+	IsExistsByAccountIDAndName(ctx context.Context, accId int, name string) (bool, error)
+	UpdateByAccountIDAndID(ctx context.Context, tag Tag) error
+	DeleteByAccountIDAndID(ctx context.Context, accId, id int) error
+	// SaveNames creates the tags that don't exist yet and
+	// returns the IDs of all tags with the given names
+	SaveNames(ctx context.Context, accId int, names []string) ([]int, error)
+	ReplaceTaskTags(ctx context.Context, taskId int, tagIds []int) error
+	GetNamesByTaskIDs(ctx context.Context, taskIds []int) (map[int][]string, error)
+}
+
+type PostgreTagRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreTagRepository(db *sqlx.DB, logger *slog.Logger) PostgreTagRepository {
+	return PostgreTagRepository{db: db, logger: logger}
+}
+
+func (repo PostgreTagRepository) Save(ctx context.Context, tag Tag) (int, error) {
+	q := `INSERT INTO tags (account_id, name) VALUES ($1, $2) RETURNING id`
+
+	var id int
+	row := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, tag.AccountID, tag.Name)
+	if err := row.Scan(&id); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving tag to database: %v", err), slog.Any("tag", tag))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreTagRepository) GetByAccountID(ctx context.Context, accId int) ([]Tag, error) {
+	q := `SELECT id, name, created_at, updated_at FROM tags WHERE account_id = $1 ORDER BY name`
+
+	var tags []Tag
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &tags, q, accId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching list of tags: %v", err), slog.Int("account_id", accId))
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (repo PostgreTagRepository) GetByAccountIDAndID(ctx context.Context, accId, id int) (Tag, error) {
+	q := `SELECT id, name, created_at, updated_at FROM tags WHERE account_id = $1 AND id = $2`
+
+	var tag Tag
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &tag, q, accId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Tag{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a single tag: %v", err), slog.Int("account_id", accId), slog.Int("id", id))
+		return Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func (repo PostgreTagRepository) IsExistsByAccountIDAndName(ctx context.Context, accId int, name string) (bool, error) {
+	q := `SELECT id FROM tags WHERE account_id = $1 AND name = $2`
+
+	var id int
+	row := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, accId, name)
+	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on checking tag existence by name: %v", err), slog.String("name", name))
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (repo PostgreTagRepository) UpdateByAccountIDAndID(ctx context.Context, tag Tag) error {
+	q := `UPDATE tags SET name = $1, updated_at = CURRENT_TIMESTAMP WHERE account_id = $2 AND id = $3`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, tag.Name, tag.AccountID, tag.ID)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating a tag: %v", err), slog.Int("id", tag.ID))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreTagRepository) DeleteByAccountIDAndID(ctx context.Context, accId, id int) error {
+	q := `DELETE FROM tags WHERE account_id = $1 AND id = $2`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting a tag: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreTagRepository) SaveNames(ctx context.Context, accId int, names []string) ([]int, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	// Updating on conflict makes the existing rows returned as well
+	q := `INSERT INTO tags (account_id, name) SELECT $1, unnest($2::varchar[])
+		ON CONFLICT (account_id, name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id`
+
+	var ids []int
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &ids, q, accId, names); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving tag names: %v", err), slog.Int("account_id", accId))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (repo PostgreTagRepository) ReplaceTaskTags(ctx context.Context, taskId int, tagIds []int) error {
+	conn := database.Conn(ctx, repo.db)
+
+	q := `DELETE FROM task_tags WHERE task_id = $1`
+	if _, err := conn.ExecContext(ctx, q, taskId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on removing task tags: %v", err), slog.Int("task_id", taskId))
+		return err
+	}
+
+	if len(tagIds) == 0 {
+		return nil
+	}
+
+	q = `INSERT INTO task_tags (task_id, tag_id) SELECT $1, unnest($2::int[])`
+	if _, err := conn.ExecContext(ctx, q, taskId, tagIds); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on adding task tags: %v", err), slog.Int("task_id", taskId))
+		return err
+	}
+
+	return nil
+}
+
+func (repo PostgreTagRepository) GetNamesByTaskIDs(ctx context.Context, taskIds []int) (map[int][]string, error) {
+	names := make(map[int][]string)
+	if len(taskIds) == 0 {
+		return names, nil
+	}
+
+	q := `SELECT tt.task_id, t.name FROM task_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.task_id = ANY($1)
+		ORDER BY t.name`
+
+	rows, err := database.Conn(ctx, repo.db).QueryContext(ctx, q, taskIds)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching tags of tasks: %v", err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId int
+		var name string
+		if err := rows.Scan(&taskId, &name); err != nil {
+			repo.logger.Error(fmt.Sprintf("error on scanning task tag: %v", err))
+			return nil, err
+		}
+
+		names[taskId] = append(names[taskId], name)
+	}
+
+	return names, rows.Err()
+}
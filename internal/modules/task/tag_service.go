@@ -0,0 +1,109 @@
+package task
+
+import (
+	"context"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+var ErrTagAlreadyExists = common.Error{
+	Code:    common.ErrCodeAlreadyExists,
+	Message: "tag with the same name already exists",
+}
+
+type TagService struct {
+	tagRepo TagRepository
+}
+
+func NewTagService(tagRepo TagRepository) TagService {
+	return TagService{tagRepo: tagRepo}
+}
+
+func (svc TagService) CreateTag(ctx context.Context, accId int, req dto.CreateTagRequest) (dto.Tag, error) {
+	tag, err := NewTag(accId, req)
+	if err != nil {
+		return dto.Tag{}, err
+	}
+
+	exists, err := svc.tagRepo.IsExistsByAccountIDAndName(ctx, accId, tag.Name)
+	if err != nil {
+		return dto.Tag{}, err
+	}
+
+	if exists {
+		return dto.Tag{}, ErrTagAlreadyExists
+	}
+
+	id, err := svc.tagRepo.Save(ctx, tag)
+	if err != nil {
+		return dto.Tag{}, err
+	}
+
+	return svc.GetByID(ctx, accId, id)
+}
+
+func (svc TagService) GetTagList(ctx context.Context, accId int) (dto.TagList, error) {
+	tags, err := svc.tagRepo.GetByAccountID(ctx, accId)
+	if err != nil {
+		return dto.TagList{}, err
+	}
+
+	tagList := dto.TagList{Tags: []dto.Tag{}}
+	for _, tag := range tags {
+		tagList.Tags = append(tagList.Tags, tagToTagDTO(tag))
+	}
+
+	return tagList, nil
+}
+
+func (svc TagService) GetByID(ctx context.Context, accId, id int) (dto.Tag, error) {
+	tag, err := svc.tagRepo.GetByAccountIDAndID(ctx, accId, id)
+	if err != nil {
+		return dto.Tag{}, err
+	}
+
+	return tagToTagDTO(tag), nil
+}
+
+func (svc TagService) Update(ctx context.Context, accId int, req dto.Tag) (dto.Tag, error) {
+	tag, err := ValidateTagForUpdate(accId, req)
+	if err != nil {
+		return dto.Tag{}, err
+	}
+
+	current, err := svc.tagRepo.GetByAccountIDAndID(ctx, accId, tag.ID)
+	if err != nil {
+		return dto.Tag{}, err
+	}
+
+	if current.Name != tag.Name {
+		exists, err := svc.tagRepo.IsExistsByAccountIDAndName(ctx, accId, tag.Name)
+		if err != nil {
+			return dto.Tag{}, err
+		}
+
+		if exists {
+			return dto.Tag{}, ErrTagAlreadyExists
+		}
+	}
+
+	if err := svc.tagRepo.UpdateByAccountIDAndID(ctx, tag); err != nil {
+		return dto.Tag{}, err
+	}
+
+	return svc.GetByID(ctx, accId, tag.ID)
+}
+
+func (svc TagService) Delete(ctx context.Context, accId, id int) error {
+	return svc.tagRepo.DeleteByAccountIDAndID(ctx, accId, id)
+}
+
+func tagToTagDTO(tag Tag) dto.Tag {
+	return dto.Tag{
+		ID:        tag.ID,
+		Name:      tag.Name,
+		CreatedAt: tag.CreatedAt,
+		UpdatedAt: tag.UpdatedAt,
+	}
+}
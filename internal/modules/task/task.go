@@ -6,9 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
 	"github.com/tamboto2000/otaqku-tasks/internal/dto"
 	"github.com/vinovest/sqlx"
 )
@@ -22,14 +25,129 @@ const (
 	StatusAbandoned  = "abandoned"
 )
 
+func isValidStatus(status string) bool {
+	switch status {
+	case StatusTODO, StatusInProgress, StatusBlocked, StatusDone, StatusAbandoned:
+		return true
+	}
+
+	return false
+}
+
 type Task struct {
-	ID          int       `db:"id"`
-	AccountID   int       `db:"account_id"`
-	Title       string    `db:"title"`
-	Description string    `db:"description"`
-	Status      string    `db:"status"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          int        `db:"id"`
+	AccountID   int        `db:"account_id"`
+	ParentID    *int       `db:"parent_id"`
+	ProjectID   *int       `db:"project_id"`
+	Title       string     `db:"title"`
+	Description string     `db:"description"`
+	Status      string     `db:"status"`
+	Priority    Priority   `db:"priority"`
+	Rank        string     `db:"rank"`
+	StartAt     *time.Time `db:"start_at"`
+	DueAt       *time.Time `db:"due_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+	AbandonedAt *time.Time `db:"abandoned_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+	// Version is incremented on every change of the task
+	Version int `db:"version"`
+	// Estimate is the expected time to spend on the task in seconds
+	Estimate *int `db:"estimate"`
+	// SeriesID is the series of a recurring task,
+	// Occurrence is the position of the task in it starting from 1
+	SeriesID   *int `db:"series_id"`
+	Occurrence int  `db:"occurrence"`
+	// Recurrence is the RRULE of the task's series, empty if the task is not recurring
+	Recurrence string `db:"-"`
+	// Tags are stored separately through TagRepository.
+	// A nil Tags on update leaves the task tags untouched.
+	Tags []string `db:"-"`
+	// Subtasks is the progress of the task's direct subtasks
+	Subtasks SubtaskProgress `db:"-"`
+	// CommentCount is the number of comments on the task
+	CommentCount int `db:"-"`
+	// Checklist is the progress of the task's checklist items
+	Checklist ChecklistProgress `db:"-"`
+}
+
+type SubtaskProgress struct {
+	Done  int `db:"done"`
+	Total int `db:"total"`
+}
+
+// IsOverdue reports whether the task has passed its due date
+// without reaching a final status.
+func (task Task) IsOverdue(now time.Time) bool {
+	if task.DueAt == nil {
+		return false
+	}
+
+	if task.Status == StatusDone || task.Status == StatusAbandoned {
+		return false
+	}
+
+	return task.DueAt.Before(now)
+}
+
+// applyStatusTimestamps records when the task reaches a final status,
+// based on its previous state. The record is cleared once the task
+// leaves the status.
+func (task *Task) applyStatusTimestamps(prev Task, now time.Time) {
+	task.CompletedAt = statusTimestamp(task.Status, prev.Status, StatusDone, prev.CompletedAt, now)
+	task.AbandonedAt = statusTimestamp(task.Status, prev.Status, StatusAbandoned, prev.AbandonedAt, now)
+}
+
+func statusTimestamp(status, prevStatus, target string, prevTimestamp *time.Time, now time.Time) *time.Time {
+	if status != target {
+		return nil
+	}
+
+	if prevStatus == target && prevTimestamp != nil {
+		return prevTimestamp
+	}
+
+	t := now.UTC()
+
+	return &t
+}
+
+// toUTC normalizes t to UTC, since timestamps are stored without time zone.
+func toUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	utc := t.UTC()
+
+	return &utc
+}
+
+func validateSchedule(startAt, dueAt *time.Time) *common.FieldError {
+	if startAt == nil || dueAt == nil {
+		return nil
+	}
+
+	if dueAt.Before(*startAt) {
+		return &common.FieldError{
+			Name:     "due_at",
+			Messages: []string{"due_at can not be before start_at"},
+		}
+	}
+
+	return nil
+}
+
+func validateEstimate(estimate *int) *common.FieldError {
+	if estimate == nil || *estimate > 0 {
+		return nil
+	}
+
+	return &common.FieldError{
+		Name:     "estimate_seconds",
+		Messages: []string{"estimate_seconds must be greater than 0"},
+	}
 }
 
 func NewTask(accId int, req dto.CreateTaskRequest) (Task, error) {
@@ -52,6 +170,34 @@ func NewTask(accId int, req dto.CreateTaskRequest) (Task, error) {
 		errValidation.Fields = append(errValidation.Fields, errTitle)
 	}
 
+	// Validate schedule
+	if errSchedule := validateSchedule(req.StartAt, req.DueAt); errSchedule != nil {
+		errValidation.Fields = append(errValidation.Fields, *errSchedule)
+	}
+
+	// Validate priority
+	priority, errPriority := parsePriority(req.Priority)
+	if errPriority != nil {
+		errValidation.Fields = append(errValidation.Fields, *errPriority)
+	}
+
+	// Validate tags
+	tags, errTags := normalizeTagNames(req.Tags)
+	if errTags != nil {
+		errValidation.Fields = append(errValidation.Fields, *errTags)
+	}
+
+	// Validate recurrence
+	recurrence, errRecurrence := normalizeRecurrence(req.Recurrence, req.DueAt)
+	if errRecurrence != nil {
+		errValidation.Fields = append(errValidation.Fields, *errRecurrence)
+	}
+
+	// Validate estimate
+	if errEstimate := validateEstimate(req.EstimateSeconds); errEstimate != nil {
+		errValidation.Fields = append(errValidation.Fields, *errEstimate)
+	}
+
 	if len(errValidation.Fields) != 0 {
 		return Task{}, errValidation
 	}
@@ -60,7 +206,15 @@ func NewTask(accId int, req dto.CreateTaskRequest) (Task, error) {
 		AccountID:   accId,
 		Title:       req.Title,
 		Description: req.Description,
+		ParentID:    req.ParentID,
+		ProjectID:   req.ProjectID,
 		Status:      StatusTODO,
+		Priority:    priority,
+		StartAt:     toUTC(req.StartAt),
+		DueAt:       toUTC(req.DueAt),
+		Tags:        tags,
+		Recurrence:  recurrence,
+		Estimate:    req.EstimateSeconds,
 	}, nil
 }
 
@@ -71,6 +225,9 @@ func ValidateTaskForUpdate(accId int, req dto.Task) (Task, error) {
 	}
 
 	var errTitle common.FieldError
+	if len(req.Title) == 0 {
+		errTitle.Messages = append(errTitle.Messages, "title can not be empty")
+	}
 
 	if len(req.Title) > 100 {
 		errTitle.Messages = append(errTitle.Messages, "title can not be longer than 100 characters")
@@ -81,9 +238,7 @@ func ValidateTaskForUpdate(accId int, req dto.Task) (Task, error) {
 	}
 
 	var errStatus common.FieldError
-	switch req.Status {
-	case StatusTODO, StatusInProgress, StatusBlocked, StatusDone, StatusAbandoned:
-	default:
+	if !isValidStatus(req.Status) {
 		errStatus.Messages = append(errStatus.Messages, "invalid status, valid statuses are: todo, in_progress, blocked, done, and abandoned")
 	}
 
@@ -91,30 +246,110 @@ func ValidateTaskForUpdate(accId int, req dto.Task) (Task, error) {
 		errValidation.Fields = append(errValidation.Fields, errStatus)
 	}
 
+	if errSchedule := validateSchedule(req.StartAt, req.DueAt); errSchedule != nil {
+		errValidation.Fields = append(errValidation.Fields, *errSchedule)
+	}
+
+	priority, errPriority := parsePriority(req.Priority)
+	if errPriority != nil {
+		errValidation.Fields = append(errValidation.Fields, *errPriority)
+	}
+
+	tags, errTags := normalizeTagNames(req.Tags)
+	if errTags != nil {
+		errValidation.Fields = append(errValidation.Fields, *errTags)
+	}
+
+	recurrence, errRecurrence := normalizeRecurrence(req.Recurrence, req.DueAt)
+	if errRecurrence != nil {
+		errValidation.Fields = append(errValidation.Fields, *errRecurrence)
+	}
+
+	if errScope := validateSeriesScope(req.Scope); errScope != nil {
+		errValidation.Fields = append(errValidation.Fields, *errScope)
+	}
+
+	if errEstimate := validateEstimate(req.EstimateSeconds); errEstimate != nil {
+		errValidation.Fields = append(errValidation.Fields, *errEstimate)
+	}
+
 	if len(errValidation.Fields) != 0 {
 		return Task{}, errValidation
 	}
 
+	// The update replaces the whole task, so no tags means removing them
+	if tags == nil {
+		tags = []string{}
+	}
+
 	return Task{
 		ID:          req.ID,
 		AccountID:   accId,
+		ProjectID:   req.ProjectID,
 		Title:       req.Title,
 		Description: req.Description,
 		Status:      req.Status,
+		Priority:    priority,
+		StartAt:     toUTC(req.StartAt),
+		DueAt:       toUTC(req.DueAt),
+		Tags:        tags,
+		Recurrence:  recurrence,
+		Estimate:    req.EstimateSeconds,
 	}, nil
 }
 
 type TaskList struct {
 	Tasks      []Task
 	Pagination dto.PaginationMetadata
+	// HasNext and HasPrev are only set on cursor pagination
+	HasNext bool
+	HasPrev bool
 }
 
 type TaskRepository interface {
-	Save(ctx context.Context, task Task) error
-	GetByAccountID(ctx context.Context, accId int, paginate dto.Pagination) (TaskList, error)
+	Save(ctx context.Context, task Task) (int, error)
+	GetByAccountID(ctx context.Context, accId int, query TaskListQuery) (TaskList, error)
 	GetByAccountIDAndID(ctx context.Context, accId, id int) (Task, error)
-	DeleteByAccountIDAndID(ctx context.Context, accId, id int) error
+	// DeleteByAccountIDAndID and UpdateByAccountIDAndID only match
+	// the task at the given version, which is then incremented
+	DeleteByAccountIDAndID(ctx context.Context, accId, id, version int) error
 	UpdateByAccountIDAndID(ctx context.Context, task Task) error
+	UpdateParentByAccountIDAndID(ctx context.Context, accId, id int, parentId *int) error
+	UpdateStatusByAccountIDAndID(ctx context.Context, accId, id int, status string) error
+	// LockHierarchyByAccountID serializes the transactions moving the account's
+	// tasks to other parents, until the transaction ends
+	LockHierarchyByAccountID(ctx context.Context, accId int) error
+	// GetAncestorIDs returns the IDs of the parent chain of a task,
+	// starting from its direct parent
+	GetAncestorIDs(ctx context.Context, id int) ([]int, error)
+	GetSubtaskProgressByParentIDs(ctx context.Context, parentIds []int) (map[int]SubtaskProgress, error)
+	GetDeletedByAccountID(ctx context.Context, accId int, paginate dto.Pagination) (TaskList, error)
+	GetDeletedByAccountIDAndID(ctx context.Context, accId, id int) (Task, error)
+	RestoreByAccountIDAndID(ctx context.Context, accId, id int) error
+	PurgeByAccountIDAndID(ctx context.Context, accId, id int) error
+	// GetLastRank returns the highest rank of the account's tasks,
+	// empty if the account has no tasks
+	GetLastRank(ctx context.Context, accId int) (string, error)
+	// GetLastRankByStatus returns the highest rank of the account's tasks
+	// with the status other than id, or empty if there is none
+	GetLastRankByStatus(ctx context.Context, accId, id int, status string) (string, error)
+	// GetAdjacentRank returns the closest rank after the given rank, or before it
+	// if before is true, among the account's tasks other than id, optionally only
+	// those with the status. Empty is returned if there is no such task.
+	GetAdjacentRank(ctx context.Context, accId, id int, rank string, status *string, before bool) (string, error)
+	UpdateRankByAccountIDAndID(ctx context.Context, accId, id int, rank string) error
+	// IsOccurrenceExists tells whether the occurrence of a series has been created,
+	// including occurrences in the trash
+	IsOccurrenceExists(ctx context.Context, seriesId, occurrence int) (bool, error)
+	// DeleteByAccountIDAndProjectID moves the tasks of a project to the trash
+	// and returns the IDs of the deleted tasks
+	DeleteByAccountIDAndProjectID(ctx context.Context, accId, projectId int) ([]int, error)
+	// ClearProjectByAccountIDAndProjectID moves the tasks of a project to the inbox
+	// and returns the IDs of the moved tasks
+	ClearProjectByAccountIDAndProjectID(ctx context.Context, accId, projectId int) ([]int, error)
+	// PurgeDeletedBefore permanently deletes the tasks of all accounts
+	// that were deleted before the given time
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int, error)
 }
 
 type PostgreTaskRepository struct {
@@ -126,46 +361,46 @@ func NewPostgreTaskRepository(db *sqlx.DB, logger *slog.Logger) PostgreTaskRepos
 	return PostgreTaskRepository{db: db, logger: logger}
 }
 
-func (repo PostgreTaskRepository) Save(ctx context.Context, task Task) error {
-	q := `INSERT INTO tasks (account_id, title, description, status) 
-		VALUES (:account_id, :title, :description, :status)`
+func (repo PostgreTaskRepository) Save(ctx context.Context, task Task) (int, error) {
+	q := `INSERT INTO tasks (account_id, parent_id, project_id, series_id, occurrence, title, description, status, priority, rank, start_at, due_at, estimate) 
+		VALUES (:account_id, :parent_id, :project_id, :series_id, :occurrence, :title, :description, :status, :priority, :rank, :start_at, :due_at, :estimate)
+		RETURNING id`
 
-	_, err := repo.db.NamedExecContext(ctx, q, task)
+	conn := database.Conn(ctx, repo.db)
+	q, args, err := conn.BindNamed(q, task)
 	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on binding task insert query: %v", err))
+		return 0, err
+	}
+
+	var id int
+	if err := conn.QueryRowxContext(ctx, q, args...).Scan(&id); err != nil {
 		repo.logger.Error(fmt.Sprintf("error on saving task to database: %v", err), slog.Any("task", task))
-		return err
+		return 0, err
 	}
 
-	return nil
+	return id, nil
 }
 
-func (repo PostgreTaskRepository) GetByAccountID(ctx context.Context, accId int, paginate dto.Pagination) (TaskList, error) {
-	q := `SELECT id, title, status, created_at, updated_at FROM tasks WHERE account_id = $1 AND deleted_at IS NULL LIMIT $2 OFFSET $3`
+func (repo PostgreTaskRepository) GetByAccountID(ctx context.Context, accId int, query TaskListQuery) (TaskList, error) {
+	where, whereArgs := taskFilterConditions(accId, query.Filter)
 
 	var taskList TaskList
-	rows, err := repo.db.QueryxContext(ctx, q, accId, paginate.PageSize, common.GetOffset(paginate.Page, paginate.PageSize))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return TaskList{}, common.ErrNotFound
-		}
+	var err error
+	if query.UseCursor {
+		taskList, err = repo.getPageByCursor(ctx, where, whereArgs, query)
+	} else {
+		taskList, err = repo.getPageByOffset(ctx, where, whereArgs, query)
+	}
 
+	if err != nil {
 		repo.logger.Error(fmt.Sprintf("error on fetching list of tasks: %v", err), slog.Int("account_id", accId))
 		return TaskList{}, err
 	}
 
-	for rows.Next() {
-		var task Task
-		if err := rows.StructScan(&task); err != nil {
-			repo.logger.Error(fmt.Sprintf("error on scanning task from database to struct: %v", err))
-			return TaskList{}, err
-		}
-
-		taskList.Tasks = append(taskList.Tasks, task)
-	}
-
 	// Get the total count of account's tasks
-	q = `SELECT COUNT(id) FROM tasks WHERE account_id = $1 AND deleted_at IS NULL`
-	row := repo.db.QueryRowContext(ctx, q, accId)
+	q := repo.db.Rebind(`SELECT COUNT(id) FROM tasks WHERE ` + where)
+	row := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, whereArgs...)
 	var totalCount int
 	if err := row.Scan(&totalCount); err != nil {
 		repo.logger.Error(fmt.Sprintf("error on fetching account's total task count: %v", err), slog.Int("account_id", accId))
@@ -173,7 +408,7 @@ func (repo PostgreTaskRepository) GetByAccountID(ctx context.Context, accId int,
 	}
 
 	pageMetaData := dto.PaginationMetadata{
-		Pagination: paginate,
+		Pagination: query.Pagination,
 		Total:      totalCount,
 	}
 
@@ -182,11 +417,109 @@ func (repo PostgreTaskRepository) GetByAccountID(ctx context.Context, accId int,
 	return taskList, nil
 }
 
+const taskListColumns = `id, parent_id, project_id, series_id, occurrence, title, status, priority, rank, start_at, due_at, estimate, completed_at, abandoned_at, created_at, updated_at, version`
+
+// prefixColumns qualifies comma separated columns with a table alias
+func prefixColumns(alias, columns string) string {
+	cols := strings.Split(columns, ", ")
+	for i, col := range cols {
+		cols[i] = alias + "." + col
+	}
+
+	return strings.Join(cols, ", ")
+}
+
+func (repo PostgreTaskRepository) getPageByOffset(ctx context.Context, where string, whereArgs []any, query TaskListQuery) (TaskList, error) {
+	paginate := query.Pagination
+	q := repo.db.Rebind(`SELECT ` + taskListColumns + ` FROM tasks WHERE ` + where +
+		` ORDER BY ` + query.Sort.orderBy() + ` LIMIT ? OFFSET ?`)
+	args := append(slices.Clone(whereArgs), paginate.PageSize, common.GetOffset(paginate.Page, paginate.PageSize))
+
+	tasks, err := repo.queryTasks(ctx, q, args...)
+	if err != nil {
+		return TaskList{}, err
+	}
+
+	return TaskList{Tasks: tasks}, nil
+}
+
+// getPageByCursor fetches a page with keyset pagination, continuing from
+// the position of the cursor. One extra row is fetched to find out whether
+// there is more data past the page.
+func (repo PostgreTaskRepository) getPageByCursor(ctx context.Context, where string, whereArgs []any, query TaskListQuery) (TaskList, error) {
+	pageSize := query.Pagination.PageSize
+	cursor := query.Cursor
+	backward := cursor != nil && cursor.Backward
+
+	// Walking backward reverses the order, the result is reversed back afterwards
+	sort := TaskSort{Field: query.Sort.Field, Desc: query.Sort.Desc != backward}
+
+	args := slices.Clone(whereArgs)
+	if cursor != nil {
+		cmp := ">"
+		if sort.Desc {
+			cmp = "<"
+		}
+
+		where += fmt.Sprintf(" AND (%s, id) %s (?, ?)", sort.Field, cmp)
+		args = append(args, cursor.Value, cursor.ID)
+	}
+
+	q := repo.db.Rebind(`SELECT ` + taskListColumns + ` FROM tasks WHERE ` + where +
+		` ORDER BY ` + sort.orderBy() + ` LIMIT ?`)
+	args = append(args, pageSize+1)
+
+	tasks, err := repo.queryTasks(ctx, q, args...)
+	if err != nil {
+		return TaskList{}, err
+	}
+
+	hasMore := len(tasks) > pageSize
+	if hasMore {
+		tasks = tasks[:pageSize]
+	}
+
+	taskList := TaskList{Tasks: tasks}
+	if backward {
+		slices.Reverse(taskList.Tasks)
+		taskList.HasPrev = hasMore
+		taskList.HasNext = true
+	} else {
+		taskList.HasPrev = cursor != nil
+		taskList.HasNext = hasMore
+	}
+
+	return taskList, nil
+}
+
+func (repo PostgreTaskRepository) queryTasks(ctx context.Context, q string, args ...any) ([]Task, error) {
+	rows, err := database.Conn(ctx, repo.db).QueryxContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var task Task
+		if err := rows.StructScan(&task); err != nil {
+			repo.logger.Error(fmt.Sprintf("error on scanning task from database to struct: %v", err))
+			return nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
 func (repo PostgreTaskRepository) GetByAccountIDAndID(ctx context.Context, accId, id int) (Task, error) {
-	q := `SELECT id, title, description, status, created_at, updated_at FROM tasks WHERE account_id = $1 AND id = $2 AND deleted_at IS NULL`
+	q := `SELECT id, account_id, parent_id, project_id, series_id, occurrence, title, description, status, priority, rank, start_at, due_at, estimate, completed_at, abandoned_at, created_at, updated_at, version
+		FROM tasks WHERE account_id = $1 AND id = $2 AND deleted_at IS NULL`
 
 	var task Task
-	row := repo.db.QueryRowxContext(ctx, q, accId, id)
+	row := database.Conn(ctx, repo.db).QueryRowxContext(ctx, q, accId, id)
 	if err := row.StructScan(&task); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Task{}, common.ErrNotFound
@@ -199,33 +532,134 @@ func (repo PostgreTaskRepository) GetByAccountIDAndID(ctx context.Context, accId
 	return task, nil
 }
 
-func (repo PostgreTaskRepository) DeleteByAccountIDAndID(ctx context.Context, accId, id int) error {
-	q := `UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP WHERE account_id = $1 AND id = $2`
+func (repo PostgreTaskRepository) DeleteByAccountIDAndID(ctx context.Context, accId, id, version int) error {
+	q := `UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE account_id = $1 AND id = $2 AND version = $3 AND deleted_at IS NULL`
 
-	_, err := repo.db.ExecContext(ctx, q, accId, id)
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, id, version)
 	if err != nil {
 		repo.logger.Error(fmt.Sprintf("error on deleting a task: %v", err), slog.Int("id", id))
 		return err
 	}
 
-	return nil
+	return common.NotFoundIfNoRowsAffected(res)
 }
 
 func (repo PostgreTaskRepository) UpdateByAccountIDAndID(ctx context.Context, task Task) error {
 	q := `UPDATE public.tasks
 SET
-    title       = COALESCE(NULLIF(:title, ''), title),
-    description = COALESCE(NULLIF(:description, ''), description),
-    status      = COALESCE(NULLIF(:status, ''), status),
-    updated_at  = CURRENT_TIMESTAMP
+    project_id   = :project_id,
+    series_id    = :series_id,
+    occurrence   = :occurrence,
+    title        = :title,
+    description  = :description,
+    status       = :status,
+    priority     = :priority,
+    start_at     = :start_at,
+    due_at       = :due_at,
+    estimate     = :estimate,
+    completed_at = :completed_at,
+    abandoned_at = :abandoned_at,
+    updated_at   = CURRENT_TIMESTAMP,
+    version      = version + 1
 WHERE account_id = :account_id
-  AND id = :id`
+  AND id = :id
+  AND version = :version
+  AND deleted_at IS NULL`
 
-	_, err := repo.db.NamedExecContext(ctx, q, task)
+	res, err := database.Conn(ctx, repo.db).NamedExecContext(ctx, q, task)
 	if err != nil {
 		repo.logger.Error(fmt.Sprintf("error on updating a task: %v", err), slog.Int("id", task.ID))
 		return err
 	}
 
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreTaskRepository) UpdateParentByAccountIDAndID(ctx context.Context, accId, id int, parentId *int) error {
+	q := `UPDATE tasks SET parent_id = $1, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE account_id = $2 AND id = $3 AND deleted_at IS NULL`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, parentId, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating task parent: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreTaskRepository) UpdateStatusByAccountIDAndID(ctx context.Context, accId, id int, status string) error {
+	q := `UPDATE tasks SET status = $1, updated_at = CURRENT_TIMESTAMP, version = version + 1 WHERE account_id = $2 AND id = $3 AND deleted_at IS NULL`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, status, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on updating task status: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreTaskRepository) LockHierarchyByAccountID(ctx context.Context, accId int) error {
+	q := `SELECT pg_advisory_xact_lock(hashtext('task_hierarchy'), $1::int)`
+
+	if _, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on locking task hierarchy: %v", err), slog.Int("account_id", accId))
+		return err
+	}
+
 	return nil
 }
+
+func (repo PostgreTaskRepository) GetAncestorIDs(ctx context.Context, id int) ([]int, error) {
+	q := `WITH RECURSIVE ancestors AS (
+		SELECT parent_id AS id, 1 AS depth FROM tasks WHERE id = $1 AND parent_id IS NOT NULL
+		UNION
+		SELECT t.parent_id, a.depth + 1 FROM tasks t
+		JOIN ancestors a ON t.id = a.id
+		WHERE t.parent_id IS NOT NULL
+	)
+	SELECT id FROM ancestors ORDER BY depth`
+
+	var ids []int
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &ids, q, id); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching task ancestors: %v", err), slog.Int("id", id))
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (repo PostgreTaskRepository) GetSubtaskProgressByParentIDs(ctx context.Context, parentIds []int) (map[int]SubtaskProgress, error) {
+	progress := make(map[int]SubtaskProgress)
+	if len(parentIds) == 0 {
+		return progress, nil
+	}
+
+	// Abandoned subtasks are not counted, as they will never be done
+	q := `SELECT parent_id, COUNT(*) FILTER (WHERE status = $1) AS done, COUNT(*) AS total
+		FROM tasks
+		WHERE parent_id = ANY($2) AND status <> $3 AND deleted_at IS NULL
+		GROUP BY parent_id`
+
+	rows, err := database.Conn(ctx, repo.db).QueryxContext(ctx, q, StatusDone, parentIds, StatusAbandoned)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching subtask progress: %v", err))
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var parentId int
+		var p SubtaskProgress
+		if err := rows.Scan(&parentId, &p.Done, &p.Total); err != nil {
+			repo.logger.Error(fmt.Sprintf("error on scanning subtask progress: %v", err))
+			return nil, err
+		}
+
+		progress[parentId] = p
+	}
+
+	return progress, rows.Err()
+}
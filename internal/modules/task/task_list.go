@@ -0,0 +1,399 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+// Sortable task fields
+const (
+	SortFieldCreatedAt = "created_at"
+	SortFieldUpdatedAt = "updated_at"
+	SortFieldTitle     = "title"
+	SortFieldPriority  = "priority"
+	SortFieldRank      = "rank"
+)
+
+// Sort directions
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+var sortableFields = []string{SortFieldCreatedAt, SortFieldUpdatedAt, SortFieldTitle, SortFieldPriority, SortFieldRank}
+
+type TaskSort struct {
+	Field string
+	Desc  bool
+}
+
+var defaultTaskSort = TaskSort{Field: SortFieldCreatedAt}
+
+// ParseTaskSort parses sort expression formatted as field:direction.
+// The direction is optional and defaults to ascending.
+func ParseTaskSort(s string) (TaskSort, error) {
+	if s == "" {
+		return defaultTaskSort, nil
+	}
+
+	field, dir, _ := strings.Cut(s, ":")
+
+	var sort TaskSort
+	switch field {
+	case SortFieldCreatedAt, SortFieldUpdatedAt, SortFieldTitle, SortFieldPriority, SortFieldRank:
+		sort.Field = field
+	default:
+		return TaskSort{}, fmt.Errorf("invalid sort field, valid fields are: %s", strings.Join(sortableFields, ", "))
+	}
+
+	switch dir {
+	case "", SortAsc:
+	case SortDesc:
+		sort.Desc = true
+	default:
+		return TaskSort{}, fmt.Errorf("invalid sort direction, valid directions are: %s and %s", SortAsc, SortDesc)
+	}
+
+	return sort, nil
+}
+
+func (sort TaskSort) direction() string {
+	if sort.Desc {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+// orderBy returns the ORDER BY expression of the sort.
+// The task ID is used as the tie breaker so the order is stable.
+func (sort TaskSort) orderBy() string {
+	dir := sort.direction()
+
+	return fmt.Sprintf("%s %s, id %s", sort.Field, dir, dir)
+}
+
+func (sort TaskSort) String() string {
+	dir := SortAsc
+	if sort.Desc {
+		dir = SortDesc
+	}
+
+	return sort.Field + ":" + dir
+}
+
+// value returns the value of the sorted field of task
+func (sort TaskSort) value(task Task) any {
+	switch sort.Field {
+	case SortFieldUpdatedAt:
+		return task.UpdatedAt
+	case SortFieldTitle:
+		return task.Title
+	case SortFieldPriority:
+		return int(task.Priority)
+	case SortFieldRank:
+		return task.Rank
+	default:
+		return task.CreatedAt
+	}
+}
+
+// parseValue converts a value decoded from a cursor
+// into the type of the sorted field
+func (sort TaskSort) parseValue(v any) (any, error) {
+	if sort.Field == SortFieldPriority {
+		num, ok := v.(json.Number)
+		if !ok {
+			return nil, errInvalidCursor
+		}
+
+		priority, err := num.Int64()
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+
+		return priority, nil
+	}
+
+	str, ok := v.(string)
+	if !ok {
+		return nil, errInvalidCursor
+	}
+
+	switch sort.Field {
+	case SortFieldCreatedAt, SortFieldUpdatedAt:
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+
+		return t, nil
+	}
+
+	return str, nil
+}
+
+const defaultCursorPageSize = 20
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+
+	ErrInvalidCursor = common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid cursor",
+	}
+)
+
+// TaskCursor points at the task a page ends at (or starts at when
+// Backward is true), so the next query can continue from there
+// using keyset pagination
+type TaskCursor struct {
+	Sort     string `json:"sort"`
+	Value    any    `json:"value"`
+	ID       int    `json:"id"`
+	Backward bool   `json:"backward,omitempty"`
+}
+
+func newTaskCursor(sort TaskSort, task Task, backward bool) TaskCursor {
+	return TaskCursor{
+		Sort:     sort.String(),
+		Value:    sort.value(task),
+		ID:       task.ID,
+		Backward: backward,
+	}
+}
+
+type TaskListQuery struct {
+	Filter     dto.TaskFilter
+	Sort       TaskSort
+	Pagination dto.Pagination
+	// UseCursor is true when the list is paginated with cursor.
+	// Cursor is nil on the first page.
+	UseCursor bool
+	Cursor    *TaskCursor
+}
+
+func NewTaskListQuery(filter dto.TaskFilter, paginate dto.Pagination, cursor *TaskCursor) (TaskListQuery, error) {
+	errValidation := common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid filter",
+	}
+
+	// Statuses can be given either as repeated parameters
+	// or as a comma separated list
+	var statuses []string
+	for _, status := range filter.Status {
+		statuses = append(statuses, strings.Split(status, ",")...)
+	}
+
+	filter.Status = statuses
+
+	// Validate statuses
+	errStatus := common.FieldError{Name: "status"}
+	for _, status := range filter.Status {
+		if !isValidStatus(status) {
+			errStatus.Messages = append(errStatus.Messages, fmt.Sprintf("invalid status %q, valid statuses are: todo, in_progress, blocked, done, and abandoned", status))
+		}
+	}
+
+	if len(errStatus.Messages) != 0 {
+		errValidation.Fields = append(errValidation.Fields, errStatus)
+	}
+
+	// Validate tags
+	tags, errTags := normalizeTagNames(filter.Tags)
+	if errTags != nil {
+		errTags.Name = "tag"
+		errValidation.Fields = append(errValidation.Fields, *errTags)
+	}
+
+	filter.Tags = tags
+
+	switch filter.TagMode {
+	case "":
+		filter.TagMode = TagModeAny
+	case TagModeAny, TagModeAll:
+	default:
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "tag_mode",
+			Messages: []string{"invalid tag mode, valid modes are: any and all"},
+		})
+	}
+
+	// Validate ranges
+	if errRange := validateTimeRange("due_after", filter.DueAfter, filter.DueBefore); errRange != nil {
+		errValidation.Fields = append(errValidation.Fields, *errRange)
+	}
+
+	if errRange := validateTimeRange("created_after", filter.CreatedAfter, filter.CreatedBefore); errRange != nil {
+		errValidation.Fields = append(errValidation.Fields, *errRange)
+	}
+
+	if errRange := validateTimeRange("updated_after", filter.UpdatedAfter, filter.UpdatedBefore); errRange != nil {
+		errValidation.Fields = append(errValidation.Fields, *errRange)
+	}
+
+	// Validate sort
+	sort, err := ParseTaskSort(filter.Sort)
+	if err != nil {
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "sort",
+			Messages: []string{err.Error()},
+		})
+	}
+
+	// Validate cursor
+	useCursor := paginate.Cursor != nil
+	if cursor != nil && err == nil {
+		if err := validateTaskCursor(cursor, sort); err != nil {
+			errValidation.Fields = append(errValidation.Fields, common.FieldError{
+				Name:     "cursor",
+				Messages: []string{err.Error()},
+			})
+		}
+	}
+
+	if len(errValidation.Fields) != 0 {
+		return TaskListQuery{}, errValidation
+	}
+
+	if useCursor {
+		paginate.Page = 0
+		if paginate.PageSize <= 0 {
+			paginate.PageSize = defaultCursorPageSize
+		}
+	}
+
+	return TaskListQuery{
+		Filter:     filter,
+		Sort:       sort,
+		Pagination: paginate,
+		UseCursor:  useCursor,
+		Cursor:     cursor,
+	}, nil
+}
+
+func validateTaskCursor(cursor *TaskCursor, sort TaskSort) error {
+	if cursor.Sort != sort.String() {
+		return errors.New("cursor does not match the requested sort")
+	}
+
+	val, err := sort.parseValue(cursor.Value)
+	if err != nil {
+		return err
+	}
+
+	cursor.Value = val
+
+	return nil
+}
+
+func validateTimeRange(name string, after, before *time.Time) *common.FieldError {
+	if after == nil || before == nil {
+		return nil
+	}
+
+	if !after.Before(*before) {
+		return &common.FieldError{
+			Name:     name,
+			Messages: []string{fmt.Sprintf("%s must be before %s", name, strings.Replace(name, "_after", "_before", 1))},
+		}
+	}
+
+	return nil
+}
+
+// placeholders returns n comma separated "?" placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// likeEscaper escapes the wildcard characters of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// taskFilterConditions builds the WHERE clause of the task list query.
+// The returned clause uses "?" placeholders, so it must be rebound
+// before being executed.
+func taskFilterConditions(accId int, filter dto.TaskFilter) (string, []any) {
+	conds := []string{"account_id = ?", "deleted_at IS NULL"}
+	args := []any{accId}
+
+	if filter.ParentID != nil {
+		conds = append(conds, "parent_id = ?")
+		args = append(args, *filter.ParentID)
+	}
+
+	if filter.ProjectID != nil {
+		conds = append(conds, "project_id = ?")
+		args = append(args, *filter.ProjectID)
+	}
+
+	if filter.Inbox {
+		conds = append(conds, "project_id IS NULL")
+	}
+
+	if len(filter.Status) != 0 {
+		conds = append(conds, "status IN ("+placeholders(len(filter.Status))+")")
+		for _, status := range filter.Status {
+			args = append(args, status)
+		}
+	}
+
+	if filter.Query != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Query) + "%"
+		conds = append(conds, "(title ILIKE ? OR description ILIKE ?)")
+		args = append(args, pattern, pattern)
+	}
+
+	if len(filter.Tags) != 0 {
+		subq := `id IN (SELECT tt.task_id FROM task_tags tt
+			JOIN tags t ON t.id = tt.tag_id
+			WHERE t.account_id = ? AND t.name IN (` + placeholders(len(filter.Tags)) + `)`
+		args = append(args, accId)
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+
+		// Tasks having all of the tags match every tag name
+		if filter.TagMode == TagModeAll {
+			subq += ` GROUP BY tt.task_id HAVING COUNT(*) = ?`
+			args = append(args, len(filter.Tags))
+		}
+
+		conds = append(conds, subq+")")
+	}
+
+	if filter.Overdue {
+		// due_at is stored in UTC without a time zone, the time is passed in UTC
+		// so the comparison does not depend on the session time zone
+		conds = append(conds, "due_at < ?", "status NOT IN (?, ?)")
+		args = append(args, time.Now().UTC(), StatusDone, StatusAbandoned)
+	}
+
+	timeRanges := []struct {
+		cond string
+		val  *time.Time
+	}{
+		{"due_at < ?", filter.DueBefore},
+		{"due_at > ?", filter.DueAfter},
+		{"created_at < ?", filter.CreatedBefore},
+		{"created_at > ?", filter.CreatedAfter},
+		{"updated_at < ?", filter.UpdatedBefore},
+		{"updated_at > ?", filter.UpdatedAfter},
+	}
+
+	for _, tr := range timeRanges {
+		if tr.val != nil {
+			conds = append(conds, tr.cond)
+			args = append(args, tr.val.UTC())
+		}
+	}
+
+	return strings.Join(conds, " AND "), args
+}
@@ -2,33 +2,249 @@ package task
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
 	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/tamboto2000/otaqku-tasks/pkg/cursor"
+	"github.com/tamboto2000/otaqku-tasks/pkg/jsonpatch"
+	"github.com/tamboto2000/otaqku-tasks/pkg/rank"
 )
 
+// ErrVersionMismatch means the task has changed since the version the client knows
+var ErrVersionMismatch = common.Error{
+	Code:    common.ErrCodePreconditionFailed,
+	Message: "Task has been modified",
+}
+
+// checkVersion returns ErrVersionMismatch if the task is not at version.
+// Version 0 matches any version.
+func checkVersion(task Task, version int) error {
+	if version != 0 && task.Version != version {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// versionMismatchIfNotFound maps a not found error of a write to ErrVersionMismatch.
+// The task was read earlier in the same transaction, so the write not matching
+// means the task was changed in the meantime.
+func versionMismatchIfNotFound(err error) error {
+	if errors.Is(err, common.ErrNotFound) {
+		return ErrVersionMismatch
+	}
+
+	return err
+}
+
 type TaskService struct {
-	taskRepo TaskRepository
+	taskRepo      TaskRepository
+	tagRepo       TagRepository
+	depRepo       DependencyRepository
+	historyRepo   HistoryRepository
+	seriesRepo    SeriesRepository
+	commentRepo   CommentRepository
+	checklistRepo ChecklistRepository
+	contents      ContentCollector
+	transactor    database.Transactor
+	cursorSigner  cursor.Signer
+	transitions   TransitionTable
+	projects      ProjectChecker
 }
 
-func NewTaskService(taskRepo TaskRepository) TaskService {
-	return TaskService{taskRepo: taskRepo}
+func NewTaskService(
+	taskRepo TaskRepository,
+	tagRepo TagRepository,
+	depRepo DependencyRepository,
+	historyRepo HistoryRepository,
+	seriesRepo SeriesRepository,
+	commentRepo CommentRepository,
+	checklistRepo ChecklistRepository,
+	contents ContentCollector,
+	transactor database.Transactor,
+	cursorSigner cursor.Signer,
+	transitions TransitionTable,
+	projects ProjectChecker,
+) TaskService {
+	return TaskService{
+		taskRepo:      taskRepo,
+		tagRepo:       tagRepo,
+		depRepo:       depRepo,
+		historyRepo:   historyRepo,
+		seriesRepo:    seriesRepo,
+		commentRepo:   commentRepo,
+		checklistRepo: checklistRepo,
+		contents:      contents,
+		transactor:    transactor,
+		cursorSigner:  cursorSigner,
+		transitions:   transitions,
+		projects:      projects,
+	}
 }
 
-func (svc TaskService) CreateTask(ctx context.Context, accId int, req dto.CreateTaskRequest) error {
+func (svc TaskService) CreateTask(ctx context.Context, accId int, req dto.CreateTaskRequest) (dto.Task, error) {
 	task, err := NewTask(accId, req)
 	if err != nil {
+		return dto.Task{}, err
+	}
+
+	var created Task
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if task.ParentID != nil {
+			if err := svc.checkParent(ctx, accId, *task.ParentID); err != nil {
+				return err
+			}
+		}
+
+		if task.ProjectID != nil {
+			if err := svc.checkProject(ctx, accId, *task.ProjectID); err != nil {
+				return err
+			}
+		}
+
+		if task.Recurrence != "" {
+			seriesId, err := svc.seriesRepo.Save(ctx, newSeries(task))
+			if err != nil {
+				return err
+			}
+
+			task.SeriesID, task.Occurrence = &seriesId, 1
+		}
+
+		id, err := svc.insertTask(ctx, accId, task)
+		if err != nil {
+			return err
+		}
+
+		created, err = svc.getTaskWithRelations(ctx, accId, id)
+
 		return err
+	})
+
+	if err != nil {
+		return dto.Task{}, err
 	}
 
-	err = svc.taskRepo.Save(ctx, task)
+	return taskToTaskDTO(created, time.Now()), nil
+}
 
-	return err
+// insertTask saves a new task at the end of the user defined order,
+// along with its tags and its history
+func (svc TaskService) insertTask(ctx context.Context, accId int, task Task) (int, error) {
+	lastRank, err := svc.taskRepo.GetLastRank(ctx, accId)
+	if err != nil {
+		return 0, err
+	}
+
+	task.Rank, err = rank.Between(lastRank, "")
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := svc.taskRepo.Save(ctx, task)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := svc.saveTaskTags(ctx, accId, id, task.Tags); err != nil {
+		return 0, err
+	}
+
+	task.ID = id
+
+	return id, svc.recordChanges(ctx, accId, HistoryActionCreate, Task{}, task)
 }
 
-func (svc TaskService) GetTaskList(ctx context.Context, accId int, paginate dto.Pagination) (dto.TaskList, error) {
-	taskList, err := svc.taskRepo.GetByAccountID(ctx, accId, paginate)
+// saveTaskTags replaces the tags of a task, creating the tags that
+// don't exist yet. Nil tags leave the task tags untouched.
+func (svc TaskService) saveTaskTags(ctx context.Context, accId, taskId int, tags []string) error {
+	if tags == nil {
+		return nil
+	}
+
+	tagIds, err := svc.tagRepo.SaveNames(ctx, accId, tags)
+	if err != nil {
+		return err
+	}
+
+	return svc.tagRepo.ReplaceTaskTags(ctx, taskId, tagIds)
+}
+
+// loadRelations fills the data of tasks that are stored
+// outside of the tasks table
+func (svc TaskService) loadRelations(ctx context.Context, tasks []Task) error {
+	taskIds := make([]int, len(tasks))
+	for i, task := range tasks {
+		taskIds[i] = task.ID
+	}
+
+	tags, err := svc.tagRepo.GetNamesByTaskIDs(ctx, taskIds)
+	if err != nil {
+		return err
+	}
+
+	subtasks, err := svc.taskRepo.GetSubtaskProgressByParentIDs(ctx, taskIds)
+	if err != nil {
+		return err
+	}
+
+	comments, err := svc.commentRepo.GetCountsByTaskIDs(ctx, taskIds)
+	if err != nil {
+		return err
+	}
+
+	checklists, err := svc.checklistRepo.GetProgressByTaskIDs(ctx, taskIds)
+	if err != nil {
+		return err
+	}
+
+	var seriesIds []int
+	for _, task := range tasks {
+		if task.SeriesID != nil && !slices.Contains(seriesIds, *task.SeriesID) {
+			seriesIds = append(seriesIds, *task.SeriesID)
+		}
+	}
+
+	rules, err := svc.seriesRepo.GetRulesByIDs(ctx, seriesIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		tasks[i].Tags = tags[tasks[i].ID]
+		tasks[i].Subtasks = subtasks[tasks[i].ID]
+		tasks[i].CommentCount = comments[tasks[i].ID]
+		tasks[i].Checklist = checklists[tasks[i].ID]
+		if tasks[i].SeriesID != nil {
+			tasks[i].Recurrence = rules[*tasks[i].SeriesID]
+		}
+	}
+
+	return nil
+}
+
+func (svc TaskService) GetTaskList(ctx context.Context, accId int, filter dto.TaskFilter, paginate dto.Pagination) (dto.TaskList, error) {
+	var taskCursor *TaskCursor
+	if paginate.Cursor != nil && *paginate.Cursor != "" {
+		taskCursor = new(TaskCursor)
+		if err := svc.cursorSigner.Decode(*paginate.Cursor, taskCursor); err != nil {
+			return dto.TaskList{}, ErrInvalidCursor
+		}
+	}
+
+	query, err := NewTaskListQuery(filter, paginate, taskCursor)
+	if err != nil {
+		return dto.TaskList{}, err
+	}
+
+	taskList, err := svc.taskRepo.GetByAccountID(ctx, accId, query)
 	if err != nil {
 		if errors.Is(err, common.ErrNotFound) {
 			return dto.TaskList{}, nil
@@ -37,13 +253,36 @@ func (svc TaskService) GetTaskList(ctx context.Context, accId int, paginate dto.
 		return dto.TaskList{}, err
 	}
 
+	if err := svc.loadRelations(ctx, taskList.Tasks); err != nil {
+		return dto.TaskList{}, err
+	}
+
+	now := time.Now()
 	var taskListDto dto.TaskList
 	for _, task := range taskList.Tasks {
-		taskListDto.Tasks = append(taskListDto.Tasks, taskToTaskDTO(task))
+		taskListDto.Tasks = append(taskListDto.Tasks, taskToTaskDTO(task, now))
 	}
 
 	taskListDto.Pagination = taskList.Pagination
 
+	if query.UseCursor && len(taskList.Tasks) != 0 {
+		if taskList.HasNext {
+			last := taskList.Tasks[len(taskList.Tasks)-1]
+			taskListDto.Pagination.NextCursor, err = svc.cursorSigner.Encode(newTaskCursor(query.Sort, last, false))
+			if err != nil {
+				return dto.TaskList{}, err
+			}
+		}
+
+		if taskList.HasPrev {
+			first := taskList.Tasks[0]
+			taskListDto.Pagination.PrevCursor, err = svc.cursorSigner.Encode(newTaskCursor(query.Sort, first, true))
+			if err != nil {
+				return dto.TaskList{}, err
+			}
+		}
+	}
+
 	return taskListDto, nil
 }
 
@@ -53,29 +292,244 @@ func (svc TaskService) GetByID(ctx context.Context, accId int, id int) (dto.Task
 		return dto.Task{}, err
 	}
 
-	return taskToTaskDTO(task), nil
+	tasks := []Task{task}
+	if err := svc.loadRelations(ctx, tasks); err != nil {
+		return dto.Task{}, err
+	}
+
+	return taskToTaskDTO(tasks[0], time.Now()), nil
 }
 
-func taskToTaskDTO(task Task) dto.Task {
+func taskToTaskDTO(task Task, now time.Time) dto.Task {
+	var subtasks *dto.SubtaskProgress
+	if task.Subtasks.Total != 0 {
+		subtasks = &dto.SubtaskProgress{
+			Done:  task.Subtasks.Done,
+			Total: task.Subtasks.Total,
+		}
+	}
+
 	return dto.Task{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
+		ID:              task.ID,
+		ParentID:        task.ParentID,
+		ProjectID:       task.ProjectID,
+		Title:           task.Title,
+		Description:     task.Description,
+		Status:          task.Status,
+		Priority:        task.Priority.String(),
+		Rank:            task.Rank,
+		StartAt:         task.StartAt,
+		DueAt:           task.DueAt,
+		EstimateSeconds: task.Estimate,
+		CompletedAt:     task.CompletedAt,
+		AbandonedAt:     task.AbandonedAt,
+		Overdue:         task.IsOverdue(now),
+		Tags:            task.Tags,
+		Subtasks:        subtasks,
+		Recurrence:      task.Recurrence,
+		SeriesID:        task.SeriesID,
+		Occurrence:      task.Occurrence,
+		CommentCount:    task.CommentCount,
+		ChecklistDone:   task.Checklist.Done,
+		ChecklistTotal:  task.Checklist.Total,
+		CreatedAt:       task.CreatedAt,
+		UpdatedAt:       task.UpdatedAt,
+		DeletedAt:       task.DeletedAt,
+		Version:         task.Version,
 	}
 }
 
-func (svc TaskService) Update(ctx context.Context, accId int, req dto.Task) error {
+// Update replaces a task with req. The task is only updated
+// at req.Version, a zero version updates the task at any version.
+func (svc TaskService) Update(ctx context.Context, accId int, req dto.Task) (dto.Task, error) {
 	task, err := ValidateTaskForUpdate(accId, req)
 	if err != nil {
-		return err
+		return dto.Task{}, err
+	}
+
+	var updated Task
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := svc.getTaskWithRelations(ctx, accId, task.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(current, req.Version); err != nil {
+			return err
+		}
+
+		if err := svc.transitions.Validate(current.Status, task.Status); err != nil {
+			return err
+		}
+
+		// Tasks can stay in an archived project, but not be moved into one
+		if task.ProjectID != nil && (current.ProjectID == nil || *current.ProjectID != *task.ProjectID) {
+			if err := svc.checkProject(ctx, accId, *task.ProjectID); err != nil {
+				return err
+			}
+		}
+
+		task.applyStatusTimestamps(current, time.Now())
+
+		if task.Status == StatusDone && current.Status != StatusDone {
+			if err := svc.checkBlockers(ctx, task.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := svc.applyRecurrence(ctx, accId, current, &task, req.Scope); err != nil {
+			return err
+		}
+
+		task.Version = current.Version
+		if err := svc.taskRepo.UpdateByAccountIDAndID(ctx, task); err != nil {
+			return versionMismatchIfNotFound(err)
+		}
+
+		if err := svc.saveTaskTags(ctx, accId, task.ID, task.Tags); err != nil {
+			return err
+		}
+
+		updated, err = svc.getTaskWithRelations(ctx, accId, task.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.recordChanges(ctx, accId, HistoryActionUpdate, current, updated); err != nil {
+			return err
+		}
+
+		if task.Status == StatusDone && current.Status != StatusDone && updated.SeriesID != nil {
+			if err := svc.createNextOccurrence(ctx, accId, updated); err != nil {
+				return err
+			}
+		}
+
+		// Closing the task may unblock the tasks waiting for it
+		if isOpenStatus(current.Status) && !isOpenStatus(task.Status) {
+			return svc.releaseBlocked(ctx, accId, task.ID)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return dto.Task{}, err
+	}
+
+	return taskToTaskDTO(updated, time.Now()), nil
+}
+
+// patchableMembers are the members of a task representation
+// a patch can change, the others are not saved by Update
+var patchableMembers = []string{
+	"title", "description", "status", "priority", "project_id",
+	"start_at", "due_at", "estimate_seconds", "tags", "recurrence",
+}
+
+// validatePatchMembers rejects a patch that modifies members Update does not save
+func validatePatchMembers(patch jsonpatch.Patch) error {
+	members, err := patch.Members()
+	if err != nil {
+		return common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid patch",
+			Details: err.Error(),
+		}
 	}
 
-	return svc.taskRepo.UpdateByAccountIDAndID(ctx, task)
+	var readOnly []string
+	for _, member := range members {
+		if !slices.Contains(patchableMembers, member) {
+			readOnly = append(readOnly, member)
+		}
+	}
+
+	if len(readOnly) != 0 {
+		return common.Error{
+			Code:    common.ErrCodeUnprocessable,
+			Message: "Patch modifies read-only fields",
+			Details: fmt.Sprintf("only %s can be patched, not: %s", strings.Join(patchableMembers, ", "), strings.Join(readOnly, ", ")),
+		}
+	}
+
+	return nil
 }
 
-func (svc TaskService) Delete(ctx context.Context, accId, id int) error {
-	return svc.taskRepo.DeleteByAccountIDAndID(ctx, accId, id)
+// Patch applies a patch document to the JSON representation of a task
+// and saves the result, as if the patched task was sent to Update with
+// the series scope. Version 0 patches the task at any version.
+func (svc TaskService) Patch(ctx context.Context, accId, id, version int, scope string, patch jsonpatch.Patch) (dto.Task, error) {
+	if err := validatePatchMembers(patch); err != nil {
+		return dto.Task{}, err
+	}
+
+	var patched dto.Task
+	err := svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := svc.getTaskWithRelations(ctx, accId, id)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(current, version); err != nil {
+			return err
+		}
+
+		doc, err := json.Marshal(taskToTaskDTO(current, time.Now()))
+		if err != nil {
+			return err
+		}
+
+		doc, err = patch.Apply(doc)
+		if err != nil {
+			return common.Error{
+				Code:    common.ErrCodeInputValidation,
+				Message: "Invalid patch",
+				Details: err.Error(),
+			}
+		}
+
+		var req dto.Task
+		if err := json.Unmarshal(doc, &req); err != nil {
+			return common.Error{
+				Code:    common.ErrCodeInputValidation,
+				Message: "Invalid patch",
+				Details: err.Error(),
+			}
+		}
+
+		req.ID = id
+		req.Version = current.Version
+		req.Scope = scope
+		patched, err = svc.Update(ctx, accId, req)
+
+		return err
+	})
+
+	return patched, err
+}
+
+// Delete moves a task to the trash. Version 0 deletes the task at any version.
+func (svc TaskService) Delete(ctx context.Context, accId, id, version int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		task, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(task, version); err != nil {
+			return err
+		}
+
+		if err := svc.taskRepo.DeleteByAccountIDAndID(ctx, accId, id, task.Version); err != nil {
+			return versionMismatchIfNotFound(err)
+		}
+
+		if err := svc.recordAction(ctx, accId, HistoryActionDelete, task); err != nil {
+			return err
+		}
+
+		// A task in the trash no longer blocks anything
+		return svc.releaseBlocked(ctx, accId, id)
+	})
 }
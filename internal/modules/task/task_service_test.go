@@ -0,0 +1,60 @@
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/pkg/jsonpatch"
+)
+
+func TestValidatePatchMembers(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    jsonpatch.Patch
+		wantCode string
+	}{
+		{name: "Merge patch of patchable fields", patch: jsonpatch.MergePatch(`{"title":"a","due_at":null,"tags":["b"]}`)},
+		{
+			name: "JSON patch of patchable fields",
+			patch: jsonpatch.JSONPatch{
+				{Op: jsonpatch.OpReplace, Path: "/status"},
+				{Op: jsonpatch.OpAdd, Path: "/tags/-"},
+				{Op: jsonpatch.OpTest, Path: "/version"},
+			},
+		},
+		{name: "Merge patch of parent", patch: jsonpatch.MergePatch(`{"parent_id":2}`), wantCode: common.ErrCodeUnprocessable},
+		{name: "Merge patch of rank", patch: jsonpatch.MergePatch(`{"title":"a","rank":"0|a"}`), wantCode: common.ErrCodeUnprocessable},
+		{
+			name:     "JSON patch of comment count",
+			patch:    jsonpatch.JSONPatch{{Op: jsonpatch.OpReplace, Path: "/comment_count"}},
+			wantCode: common.ErrCodeUnprocessable,
+		},
+		{
+			name:     "JSON patch moving a read-only field",
+			patch:    jsonpatch.JSONPatch{{Op: jsonpatch.OpMove, From: "/id", Path: "/title"}},
+			wantCode: common.ErrCodeUnprocessable,
+		},
+		{
+			name:     "JSON patch replacing the document",
+			patch:    jsonpatch.JSONPatch{{Op: jsonpatch.OpReplace, Path: ""}},
+			wantCode: common.ErrCodeUnprocessable,
+		},
+		{name: "Invalid merge patch", patch: jsonpatch.MergePatch(`{`), wantCode: common.ErrCodeInputValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePatchMembers(tt.patch)
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var xErr common.Error
+			assert.True(t, errors.As(err, &xErr))
+			assert.Equal(t, tt.wantCode, xErr.Code)
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+	"github.com/vinovest/sqlx"
+)
+
+// maxEntryDuration is the longest time entry that can be recorded manually
+const maxEntryDuration = 24 * time.Hour
+
+// ErrTimerRunning means the account already has a running timer,
+// only one can run at a time
+var ErrTimerRunning = common.Error{
+	Code:    common.ErrCodeConflict,
+	Message: "Another timer is already running",
+}
+
+// TimeEntry is time spent on a task. A running timer is
+// an entry that has not ended yet.
+type TimeEntry struct {
+	ID        int        `db:"id"`
+	TaskID    int        `db:"task_id"`
+	AccountID int        `db:"account_id"`
+	StartedAt time.Time  `db:"started_at"`
+	EndedAt   *time.Time `db:"ended_at"`
+	Note      string     `db:"note"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+}
+
+// Duration returns the time spent, up to now for a running timer
+func (entry TimeEntry) Duration(now time.Time) time.Duration {
+	if entry.EndedAt == nil {
+		return now.Sub(entry.StartedAt)
+	}
+
+	return entry.EndedAt.Sub(entry.StartedAt)
+}
+
+func validateTimeEntryNote(note string) *common.FieldError {
+	if len(note) > 500 {
+		return &common.FieldError{
+			Name:     "note",
+			Messages: []string{"note can not be longer than 500 characters"},
+		}
+	}
+
+	return nil
+}
+
+func NewTimer(accId, taskId int, req dto.StartTimerRequest, now time.Time) (TimeEntry, error) {
+	note := strings.TrimSpace(req.Note)
+	if errNote := validateTimeEntryNote(note); errNote != nil {
+		return TimeEntry{}, common.Error{
+			Code:    common.ErrCodeInputValidation,
+			Message: "Invalid input",
+			Fields:  []common.FieldError{*errNote},
+		}
+	}
+
+	return TimeEntry{
+		TaskID:    taskId,
+		AccountID: accId,
+		StartedAt: now.UTC(),
+		Note:      note,
+	}, nil
+}
+
+func NewTimeEntry(accId, taskId int, req dto.CreateTimeEntryRequest, now time.Time) (TimeEntry, error) {
+	errValidation := common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid input",
+	}
+
+	duration := time.Duration(req.DurationSeconds) * time.Second
+
+	var errDuration common.FieldError
+	if req.DurationSeconds <= 0 {
+		errDuration.Messages = append(errDuration.Messages, "duration_seconds must be greater than 0")
+	}
+
+	if duration > maxEntryDuration {
+		errDuration.Messages = append(errDuration.Messages, "duration_seconds can not be longer than 24 hours")
+	}
+
+	if len(errDuration.Messages) != 0 {
+		errDuration.Name = "duration_seconds"
+		errValidation.Fields = append(errValidation.Fields, errDuration)
+	}
+
+	startedAt := now.Add(-duration)
+	if req.StartedAt != nil {
+		startedAt = *req.StartedAt
+	}
+
+	if startedAt.Add(duration).After(now) {
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "started_at",
+			Messages: []string{"time entries can not end in the future"},
+		})
+	}
+
+	note := strings.TrimSpace(req.Note)
+	if errNote := validateTimeEntryNote(note); errNote != nil {
+		errValidation.Fields = append(errValidation.Fields, *errNote)
+	}
+
+	if len(errValidation.Fields) != 0 {
+		return TimeEntry{}, errValidation
+	}
+
+	endedAt := startedAt.Add(duration)
+
+	return TimeEntry{
+		TaskID:    taskId,
+		AccountID: accId,
+		StartedAt: startedAt.UTC(),
+		EndedAt:   toUTC(&endedAt),
+		Note:      note,
+	}, nil
+}
+
+type TimeEntryList struct {
+	Entries    []TimeEntry
+	Pagination dto.PaginationMetadata
+}
+
+// Time report groupings
+const (
+	TimeGroupByTask   = "task"
+	TimeGroupByDay    = "day"
+	TimeGroupByStatus = "status"
+)
+
+// TimeReportRow is the total of the stopped time entries of a group
+type TimeReportRow struct {
+	Key          string `db:"key"`
+	TaskID       *int   `db:"task_id"`
+	Title        string `db:"title"`
+	Estimate     *int   `db:"estimate"`
+	TotalSeconds int    `db:"total_seconds"`
+	EntryCount   int    `db:"entry_count"`
+}
+
+type TimeEntryRepository interface {
+	Save(ctx context.Context, entry TimeEntry) (int, error)
+	// SaveTimer saves a running timer, ErrTimerRunning is returned
+	// if the account already has one
+	SaveTimer(ctx context.Context, entry TimeEntry) (int, error)
+	GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int, paginate dto.Pagination) (TimeEntryList, error)
+	GetByAccountIDAndID(ctx context.Context, accId, taskId, id int) (TimeEntry, error)
+	GetRunningByAccountID(ctx context.Context, accId int) (TimeEntry, error)
+	// StopByAccountIDAndTaskID ends the running timer of a task
+	// and returns its ID
+	StopByAccountIDAndTaskID(ctx context.Context, accId, taskId int, endedAt time.Time) (int, error)
+	DeleteByAccountIDAndID(ctx context.Context, accId, taskId, id int) error
+	// GetReport sums the stopped time entries started within [from, to)
+	// of the account's tasks outside the trash
+	GetReport(ctx context.Context, accId int, from, to time.Time, groupBy string) ([]TimeReportRow, error)
+}
+
+type PostgreTimeEntryRepository struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+func NewPostgreTimeEntryRepository(db *sqlx.DB, logger *slog.Logger) PostgreTimeEntryRepository {
+	return PostgreTimeEntryRepository{db: db, logger: logger}
+}
+
+func (repo PostgreTimeEntryRepository) Save(ctx context.Context, entry TimeEntry) (int, error) {
+	q := `INSERT INTO task_time_entries (task_id, account_id, started_at, ended_at, note) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	var id int
+	err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, entry.TaskID, entry.AccountID, entry.StartedAt, entry.EndedAt, entry.Note).Scan(&id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on saving time entry: %v", err), slog.Int("task_id", entry.TaskID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreTimeEntryRepository) SaveTimer(ctx context.Context, entry TimeEntry) (int, error) {
+	q := `INSERT INTO task_time_entries (task_id, account_id, started_at, note) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (account_id) WHERE ended_at IS NULL DO NOTHING
+		RETURNING id`
+
+	var id int
+	err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, entry.TaskID, entry.AccountID, entry.StartedAt, entry.Note).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrTimerRunning
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on starting a timer: %v", err), slog.Int("task_id", entry.TaskID))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreTimeEntryRepository) GetByAccountIDAndTaskID(ctx context.Context, accId, taskId int, paginate dto.Pagination) (TimeEntryList, error) {
+	conn := database.Conn(ctx, repo.db)
+
+	q := `SELECT id, task_id, account_id, started_at, ended_at, note, created_at, updated_at
+		FROM task_time_entries
+		WHERE account_id = $1 AND task_id = $2
+		ORDER BY started_at DESC, id DESC
+		LIMIT $3 OFFSET $4`
+
+	var entries []TimeEntry
+	err := conn.SelectContext(ctx, &entries, q, accId, taskId, paginate.PageSize, common.GetOffset(paginate.Page, paginate.PageSize))
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching time entries: %v", err), slog.Int("task_id", taskId))
+		return TimeEntryList{}, err
+	}
+
+	q = `SELECT COUNT(id) FROM task_time_entries WHERE account_id = $1 AND task_id = $2`
+	var totalCount int
+	if err := conn.QueryRowContext(ctx, q, accId, taskId).Scan(&totalCount); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching time entry count: %v", err), slog.Int("task_id", taskId))
+		return TimeEntryList{}, err
+	}
+
+	return TimeEntryList{
+		Entries: entries,
+		Pagination: dto.PaginationMetadata{
+			Pagination: paginate,
+			Total:      totalCount,
+		},
+	}, nil
+}
+
+func (repo PostgreTimeEntryRepository) GetByAccountIDAndID(ctx context.Context, accId, taskId, id int) (TimeEntry, error) {
+	q := `SELECT id, task_id, account_id, started_at, ended_at, note, created_at, updated_at
+		FROM task_time_entries WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	var entry TimeEntry
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &entry, q, accId, taskId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TimeEntry{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a time entry: %v", err), slog.Int("task_id", taskId), slog.Int("id", id))
+		return TimeEntry{}, err
+	}
+
+	return entry, nil
+}
+
+func (repo PostgreTimeEntryRepository) GetRunningByAccountID(ctx context.Context, accId int) (TimeEntry, error) {
+	q := `SELECT id, task_id, account_id, started_at, ended_at, note, created_at, updated_at
+		FROM task_time_entries WHERE account_id = $1 AND ended_at IS NULL`
+
+	var entry TimeEntry
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &entry, q, accId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TimeEntry{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching the running timer: %v", err), slog.Int("account_id", accId))
+		return TimeEntry{}, err
+	}
+
+	return entry, nil
+}
+
+func (repo PostgreTimeEntryRepository) StopByAccountIDAndTaskID(ctx context.Context, accId, taskId int, endedAt time.Time) (int, error) {
+	// A timer started moments ago by a server with a skewed clock
+	// still stops after it started
+	q := `UPDATE task_time_entries SET ended_at = GREATEST($1, started_at), updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $2 AND task_id = $3 AND ended_at IS NULL
+		RETURNING id`
+
+	var id int
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, endedAt.UTC(), accId, taskId).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on stopping a timer: %v", err), slog.Int("task_id", taskId))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repo PostgreTimeEntryRepository) DeleteByAccountIDAndID(ctx context.Context, accId, taskId, id int) error {
+	q := `DELETE FROM task_time_entries WHERE account_id = $1 AND task_id = $2 AND id = $3`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, taskId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on deleting a time entry: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+// timeReportGroups are the grouping expressions of the time report
+var timeReportGroups = map[string]struct {
+	columns string
+	groupBy string
+	orderBy string
+}{
+	TimeGroupByTask: {
+		columns: `t.id::text AS key, t.id AS task_id, t.title, t.estimate`,
+		groupBy: `t.id`,
+		orderBy: `total_seconds DESC, t.id`,
+	},
+	TimeGroupByDay: {
+		columns: `to_char(e.started_at, 'YYYY-MM-DD') AS key`,
+		groupBy: `key`,
+		orderBy: `key`,
+	},
+	TimeGroupByStatus: {
+		columns: `t.status AS key`,
+		groupBy: `t.status`,
+		orderBy: `total_seconds DESC, t.status`,
+	},
+}
+
+func (repo PostgreTimeEntryRepository) GetReport(ctx context.Context, accId int, from, to time.Time, groupBy string) ([]TimeReportRow, error) {
+	group, ok := timeReportGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown time report grouping %q", groupBy)
+	}
+
+	q := `SELECT ` + group.columns + `,
+			SUM(EXTRACT(EPOCH FROM e.ended_at - e.started_at))::bigint AS total_seconds,
+			COUNT(e.id) AS entry_count
+		FROM task_time_entries e
+		JOIN tasks t ON t.id = e.task_id
+		WHERE e.account_id = $1 AND e.ended_at IS NOT NULL
+			AND e.started_at >= $2 AND e.started_at < $3
+			AND t.deleted_at IS NULL
+		GROUP BY ` + group.groupBy + `
+		ORDER BY ` + group.orderBy
+
+	var rows []TimeReportRow
+	if err := database.Conn(ctx, repo.db).SelectContext(ctx, &rows, q, accId, from.UTC(), to.UTC()); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching time report: %v", err), slog.Int("account_id", accId))
+		return nil, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,246 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+type TimeService struct {
+	timeEntryRepo TimeEntryRepository
+	taskRepo      TaskRepository
+	transactor    database.Transactor
+}
+
+func NewTimeService(timeEntryRepo TimeEntryRepository, taskRepo TaskRepository, transactor database.Transactor) TimeService {
+	return TimeService{timeEntryRepo: timeEntryRepo, taskRepo: taskRepo, transactor: transactor}
+}
+
+func (svc TimeService) GetTimeEntries(ctx context.Context, accId, id int, paginate dto.Pagination) (dto.TimeEntryList, error) {
+	if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+		return dto.TimeEntryList{}, err
+	}
+
+	entryList, err := svc.timeEntryRepo.GetByAccountIDAndTaskID(ctx, accId, id, paginate)
+	if err != nil {
+		return dto.TimeEntryList{}, err
+	}
+
+	now := time.Now()
+	entries := dto.TimeEntryList{
+		Entries:    []dto.TimeEntry{},
+		Pagination: entryList.Pagination,
+	}
+
+	for _, entry := range entryList.Entries {
+		entries.Entries = append(entries.Entries, timeEntryToTimeEntryDTO(entry, now))
+	}
+
+	return entries, nil
+}
+
+// AddTimeEntry records time spent on a task manually
+func (svc TimeService) AddTimeEntry(ctx context.Context, accId, id int, req dto.CreateTimeEntryRequest) (dto.TimeEntry, error) {
+	entry, err := NewTimeEntry(accId, id, req, time.Now())
+	if err != nil {
+		return dto.TimeEntry{}, err
+	}
+
+	var created TimeEntry
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		entryId, err := svc.timeEntryRepo.Save(ctx, entry)
+		if err != nil {
+			return err
+		}
+
+		created, err = svc.timeEntryRepo.GetByAccountIDAndID(ctx, accId, id, entryId)
+
+		return err
+	})
+
+	if err != nil {
+		return dto.TimeEntry{}, err
+	}
+
+	return timeEntryToTimeEntryDTO(created, time.Now()), nil
+}
+
+// StartTimer starts tracking time on a task. The running timer
+// is returned in the error details if the account already has one.
+func (svc TimeService) StartTimer(ctx context.Context, accId, id int, req dto.StartTimerRequest) (dto.TimeEntry, error) {
+	timer, err := NewTimer(accId, id, req, time.Now())
+	if err != nil {
+		return dto.TimeEntry{}, err
+	}
+
+	var started TimeEntry
+	err = svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		timerId, err := svc.timeEntryRepo.SaveTimer(ctx, timer)
+		if err != nil {
+			return err
+		}
+
+		started, err = svc.timeEntryRepo.GetByAccountIDAndID(ctx, accId, id, timerId)
+
+		return err
+	})
+
+	if errors.Is(err, ErrTimerRunning) {
+		running, errRunning := svc.timeEntryRepo.GetRunningByAccountID(ctx, accId)
+		if errRunning != nil {
+			// The other timer may have been stopped in the meantime
+			return dto.TimeEntry{}, err
+		}
+
+		errTimer := ErrTimerRunning
+		errTimer.Details = timeEntryToTimeEntryDTO(running, time.Now())
+
+		return dto.TimeEntry{}, errTimer
+	}
+
+	if err != nil {
+		return dto.TimeEntry{}, err
+	}
+
+	return timeEntryToTimeEntryDTO(started, time.Now()), nil
+}
+
+// StopTimer stops the running timer of a task. The task may be
+// in the trash, so a timer left running can always be stopped.
+func (svc TimeService) StopTimer(ctx context.Context, accId, id int) (dto.TimeEntry, error) {
+	var stopped TimeEntry
+	err := svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		timerId, err := svc.timeEntryRepo.StopByAccountIDAndTaskID(ctx, accId, id, time.Now())
+		if err != nil {
+			return err
+		}
+
+		stopped, err = svc.timeEntryRepo.GetByAccountIDAndID(ctx, accId, id, timerId)
+
+		return err
+	})
+
+	if err != nil {
+		return dto.TimeEntry{}, err
+	}
+
+	return timeEntryToTimeEntryDTO(stopped, time.Now()), nil
+}
+
+func (svc TimeService) DeleteTimeEntry(ctx context.Context, accId, id, entryId int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		return svc.timeEntryRepo.DeleteByAccountIDAndID(ctx, accId, id, entryId)
+	})
+}
+
+func validateTimeReportQuery(query dto.TimeReportQuery) (string, error) {
+	errValidation := common.Error{
+		Code:    common.ErrCodeInputValidation,
+		Message: "Invalid filter",
+	}
+
+	if query.From == nil {
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "from",
+			Messages: []string{"from is required"},
+		})
+	}
+
+	if query.To == nil {
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "to",
+			Messages: []string{"to is required"},
+		})
+	}
+
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "to",
+			Messages: []string{"to must be after from"},
+		})
+	}
+
+	groupBy := query.GroupBy
+	switch groupBy {
+	case "":
+		groupBy = TimeGroupByTask
+	case TimeGroupByTask, TimeGroupByDay, TimeGroupByStatus:
+	default:
+		errValidation.Fields = append(errValidation.Fields, common.FieldError{
+			Name:     "group_by",
+			Messages: []string{"invalid grouping, valid groupings are: task, day, and status"},
+		})
+	}
+
+	if len(errValidation.Fields) != 0 {
+		return "", errValidation
+	}
+
+	return groupBy, nil
+}
+
+// GetTimeReport totals the time tracked between from and to. Running timers
+// are left out until they are stopped, and entries count entirely towards
+// the range they started in.
+func (svc TimeService) GetTimeReport(ctx context.Context, accId int, query dto.TimeReportQuery) (dto.TimeReport, error) {
+	groupBy, err := validateTimeReportQuery(query)
+	if err != nil {
+		return dto.TimeReport{}, err
+	}
+
+	rows, err := svc.timeEntryRepo.GetReport(ctx, accId, *query.From, *query.To, groupBy)
+	if err != nil {
+		return dto.TimeReport{}, err
+	}
+
+	report := dto.TimeReport{
+		From:    query.From.UTC(),
+		To:      query.To.UTC(),
+		GroupBy: groupBy,
+		Groups:  []dto.TimeReportGroup{},
+	}
+
+	for _, row := range rows {
+		report.TotalSeconds += row.TotalSeconds
+		report.Groups = append(report.Groups, dto.TimeReportGroup{
+			Key:             row.Key,
+			TaskID:          row.TaskID,
+			Title:           row.Title,
+			EstimateSeconds: row.Estimate,
+			TotalSeconds:    row.TotalSeconds,
+			EntryCount:      row.EntryCount,
+		})
+	}
+
+	return report, nil
+}
+
+func timeEntryToTimeEntryDTO(entry TimeEntry, now time.Time) dto.TimeEntry {
+	return dto.TimeEntry{
+		ID:              entry.ID,
+		TaskID:          entry.TaskID,
+		StartedAt:       entry.StartedAt,
+		EndedAt:         entry.EndedAt,
+		DurationSeconds: int(entry.Duration(now) / time.Second),
+		Running:         entry.EndedAt == nil,
+		Note:            entry.Note,
+		CreatedAt:       entry.CreatedAt,
+		UpdatedAt:       entry.UpdatedAt,
+	}
+}
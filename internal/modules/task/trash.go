@@ -0,0 +1,118 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/database"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+func (repo PostgreTaskRepository) GetDeletedByAccountID(ctx context.Context, accId int, paginate dto.Pagination) (TaskList, error) {
+	q := `SELECT ` + taskListColumns + `, deleted_at FROM tasks
+		WHERE account_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	tasks, err := repo.queryTasks(ctx, q, accId, paginate.PageSize, common.GetOffset(paginate.Page, paginate.PageSize))
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching deleted tasks: %v", err), slog.Int("account_id", accId))
+		return TaskList{}, err
+	}
+
+	q = `SELECT COUNT(id) FROM tasks WHERE account_id = $1 AND deleted_at IS NOT NULL`
+	var totalCount int
+	if err := database.Conn(ctx, repo.db).QueryRowContext(ctx, q, accId).Scan(&totalCount); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on fetching account's deleted task count: %v", err), slog.Int("account_id", accId))
+		return TaskList{}, err
+	}
+
+	return TaskList{
+		Tasks: tasks,
+		Pagination: dto.PaginationMetadata{
+			Pagination: paginate,
+			Total:      totalCount,
+		},
+	}, nil
+}
+
+func (repo PostgreTaskRepository) GetDeletedByAccountIDAndID(ctx context.Context, accId, id int) (Task, error) {
+	q := `SELECT id, account_id, parent_id, project_id, series_id, occurrence, title, description, status, priority, rank, start_at, due_at, estimate, completed_at, abandoned_at, created_at, updated_at, deleted_at, version
+		FROM tasks WHERE account_id = $1 AND id = $2 AND deleted_at IS NOT NULL`
+
+	var task Task
+	if err := database.Conn(ctx, repo.db).GetContext(ctx, &task, q, accId, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Task{}, common.ErrNotFound
+		}
+
+		repo.logger.Error(fmt.Sprintf("error on fetching a deleted task: %v", err), slog.Int("account_id", accId), slog.Int("id", id))
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+func (repo PostgreTaskRepository) RestoreByAccountIDAndID(ctx context.Context, accId, id int) error {
+	q := `UPDATE tasks SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE account_id = $1 AND id = $2 AND deleted_at IS NOT NULL`
+
+	res, err := database.Conn(ctx, repo.db).ExecContext(ctx, q, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on restoring a task: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreTaskRepository) PurgeByAccountIDAndID(ctx context.Context, accId, id int) error {
+	conn := database.Conn(ctx, repo.db)
+
+	// Subtasks outlive their parent as top level tasks
+	q := `UPDATE tasks SET parent_id = NULL WHERE account_id = $1 AND parent_id = $2`
+	if _, err := conn.ExecContext(ctx, q, accId, id); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on detaching subtasks of a purged task: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	q = `DELETE FROM tasks WHERE account_id = $1 AND id = $2`
+	res, err := conn.ExecContext(ctx, q, accId, id)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on purging a task: %v", err), slog.Int("id", id))
+		return err
+	}
+
+	return common.NotFoundIfNoRowsAffected(res)
+}
+
+func (repo PostgreTaskRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int, error) {
+	conn := database.Conn(ctx, repo.db)
+
+	// Subtasks outlive their parent as top level tasks
+	q := `UPDATE tasks SET parent_id = NULL
+		WHERE parent_id IN (SELECT id FROM tasks WHERE deleted_at < $1)`
+	if _, err := conn.ExecContext(ctx, q, before.UTC()); err != nil {
+		repo.logger.Error(fmt.Sprintf("error on detaching subtasks of purged tasks: %v", err))
+		return 0, err
+	}
+
+	q = `DELETE FROM tasks WHERE deleted_at < $1`
+	res, err := conn.ExecContext(ctx, q, before.UTC())
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("error on purging deleted tasks: %v", err))
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
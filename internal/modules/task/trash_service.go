@@ -0,0 +1,143 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tamboto2000/otaqku-tasks/internal/common"
+	"github.com/tamboto2000/otaqku-tasks/internal/dto"
+)
+
+func (svc TaskService) GetTrash(ctx context.Context, accId int, paginate dto.Pagination) (dto.TaskList, error) {
+	taskList, err := svc.taskRepo.GetDeletedByAccountID(ctx, accId, paginate)
+	if err != nil {
+		return dto.TaskList{}, err
+	}
+
+	now := time.Now()
+	taskListDto := dto.TaskList{Pagination: taskList.Pagination}
+	for _, task := range taskList.Tasks {
+		taskListDto.Tasks = append(taskListDto.Tasks, taskToTaskDTO(task, now))
+	}
+
+	return taskListDto, nil
+}
+
+func (svc TaskService) Restore(ctx context.Context, accId, id int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		task, err := svc.taskRepo.GetDeletedByAccountIDAndID(ctx, accId, id)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.taskRepo.RestoreByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		if err := svc.recordAction(ctx, accId, HistoryActionRestore, task); err != nil {
+			return err
+		}
+
+		// The blockers of the task may have been closed or trashed in the meantime,
+		// and the tasks waiting for it are blocked again while it is open
+		if err := svc.unblockIfReady(ctx, accId, id); err != nil {
+			return err
+		}
+
+		return svc.blockDependents(ctx, accId, task)
+	})
+}
+
+func (svc TaskService) getTaskInOrOutOfTrash(ctx context.Context, accId, id int) (Task, error) {
+	task, err := svc.taskRepo.GetByAccountIDAndID(ctx, accId, id)
+	if errors.Is(err, common.ErrNotFound) {
+		return svc.taskRepo.GetDeletedByAccountIDAndID(ctx, accId, id)
+	}
+
+	return task, err
+}
+
+// GetByIDInOrOutOfTrash returns a task whether it is in the trash or not
+func (svc TaskService) GetByIDInOrOutOfTrash(ctx context.Context, accId, id int) (dto.Task, error) {
+	task, err := svc.getTaskInOrOutOfTrash(ctx, accId, id)
+	if err != nil {
+		return dto.Task{}, err
+	}
+
+	tasks := []Task{task}
+	if err := svc.loadRelations(ctx, tasks); err != nil {
+		return dto.Task{}, err
+	}
+
+	return taskToTaskDTO(tasks[0], time.Now()), nil
+}
+
+// DeletePermanently deletes a task for good, whether it is in the trash or not.
+// Version 0 deletes the task at any version.
+func (svc TaskService) DeletePermanently(ctx context.Context, accId, id, version int) error {
+	return svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if version != 0 {
+			task, err := svc.getTaskInOrOutOfTrash(ctx, accId, id)
+			if err != nil {
+				return err
+			}
+
+			if err := checkVersion(task, version); err != nil {
+				return err
+			}
+		}
+
+		if err := svc.contents.collectTaskAfterCommit(ctx, accId, id); err != nil {
+			return err
+		}
+
+		// The dependencies are gone along with the task
+		blocked, err := svc.depRepo.GetBlocked(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.taskRepo.PurgeByAccountIDAndID(ctx, accId, id); err != nil {
+			return err
+		}
+
+		if err := svc.recordAction(ctx, accId, HistoryActionPurge, Task{ID: id, AccountID: accId}); err != nil {
+			return err
+		}
+
+		return svc.unblockAllIfReady(ctx, accId, blocked)
+	})
+}
+
+// PurgeTrash permanently deletes the tasks of all accounts
+// that have been in the trash for longer than the retention period
+func (svc TaskService) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	var n int
+	err := svc.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		before := time.Now().Add(-retention)
+		if err := svc.contents.collectTasksDeletedBeforeAfterCommit(ctx, before); err != nil {
+			return err
+		}
+
+		blocked, err := svc.depRepo.GetBlockedByTasksDeletedBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		n, err = svc.taskRepo.PurgeDeletedBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range blocked {
+			if err := svc.unblockIfReady(ctx, task.AccountID, task.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return n, err
+}
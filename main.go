@@ -45,6 +45,9 @@ func main() {
 		}
 	}()
 
+	// Run background jobs
+	go app.RunBackgroundJobs()
+
 	stopAppOnOsSignal(app)
 }
 
@@ -2,7 +2,9 @@ package config
 
 import (
 	"encoding/base64"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -60,3 +62,54 @@ func (b64 *RawBase64Encoded) UnmarshalText(text []byte) error {
 
 	return nil
 }
+
+// StringSliceMap is a map of string slices. Its text representation is
+// semicolon separated entries, each formatted as key:value1,value2.
+// A key without values is written as key:
+type StringSliceMap map[string][]string
+
+func (m *StringSliceMap) UnmarshalText(text []byte) error {
+	result := make(StringSliceMap)
+	for _, entry := range strings.Split(string(text), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, vals, ok := strings.Cut(entry, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid entry %q, entry must be formatted as key:value1,value2", entry)
+		}
+
+		list := []string{}
+		for _, val := range strings.Split(vals, ",") {
+			if val = strings.TrimSpace(val); val != "" {
+				list = append(list, val)
+			}
+		}
+
+		result[key] = list
+	}
+
+	*m = result
+
+	return nil
+}
+
+// StringSlice is a list of strings. Its text representation is
+// comma separated values, empty values are left out.
+type StringSlice []string
+
+func (s *StringSlice) UnmarshalText(text []byte) error {
+	result := StringSlice{}
+	for _, val := range strings.Split(string(text), ",") {
+		if val = strings.TrimSpace(val); val != "" {
+			result = append(result, val)
+		}
+	}
+
+	*s = result
+
+	return nil
+}
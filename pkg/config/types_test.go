@@ -251,3 +251,73 @@ func TestRawBase64Encoded_UnmarshalText(t *testing.T) {
 		})
 	}
 }
+
+func TestStringSliceMap_UnmarshalText(t *testing.T) {
+	type args struct {
+		text []byte
+	}
+	tests := []struct {
+		name       string
+		m          *StringSliceMap
+		args       args
+		wantResult StringSliceMap
+		wantErr    bool
+	}{
+		{
+			name: "Valid string slice map",
+			m:    new(StringSliceMap),
+			args: args{
+				text: []byte("todo:in_progress,done; done:todo ;abandoned:"),
+			},
+			wantResult: StringSliceMap{
+				"todo":      {"in_progress", "done"},
+				"done":      {"todo"},
+				"abandoned": {},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Invalid entry",
+			m:    new(StringSliceMap),
+			args: args{
+				text: []byte("todo:done;in_progress"),
+			},
+			wantResult: nil,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.m.UnmarshalText(tt.args.text)
+			assert.Equal(t, tt.wantResult, *tt.m)
+
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			}
+
+			if !tt.wantErr {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestStringSlice_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		name string
+		text []byte
+		want StringSlice
+	}{
+		{name: "Values", text: []byte("image/*, text/plain,application/pdf"), want: StringSlice{"image/*", "text/plain", "application/pdf"}},
+		{name: "Empty values", text: []byte("a,,b, "), want: StringSlice{"a", "b"}},
+		{name: "Empty", text: []byte(""), want: StringSlice{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s StringSlice
+			assert.Nil(t, s.UnmarshalText(tt.text))
+			assert.Equal(t, tt.want, s)
+		})
+	}
+}
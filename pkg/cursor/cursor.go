@@ -0,0 +1,79 @@
+package cursor
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MinKeySize is the smallest signing key in bytes that should be used,
+// the size of an HMAC-SHA256 signature
+const MinKeySize = sha256.Size
+
+var encoding = base64.RawURLEncoding
+
+// Signer encodes values into opaque cursors and decodes them back.
+// A cursor is the JSON representation of the value followed by its
+// HMAC-SHA256 signature, so a tampered cursor is rejected on decoding.
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(key []byte) Signer {
+	return Signer{key: key}
+}
+
+// Encode encodes v into a signed cursor
+func (s Signer) Encode(v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(s.sign(payload)), nil
+}
+
+// Decode verifies the cursor signature and decodes its value into v.
+// ErrInvalidCursor is returned if the cursor is malformed or the signature
+// does not match.
+func (s Signer) Decode(cursor string, v any) error {
+	payloadStr, sigStr, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+
+	payload, err := encoding.DecodeString(payloadStr)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	sig, err := encoding.DecodeString(sigStr)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	if !hmac.Equal(sig, s.sign(payload)) {
+		return ErrInvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
+
+func (s Signer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,69 @@
+package cursor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCursor struct {
+	Value string `json:"value"`
+	ID    int    `json:"id"`
+}
+
+func TestSigner_EncodeDecode(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	want := testCursor{Value: "2025-09-16T02:20:43.123456Z", ID: 10}
+
+	cursor, err := signer.Encode(want)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var got testCursor
+	if err := signer.Decode(cursor, &got); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestSigner_Decode(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	cursor, err := signer.Encode(testCursor{Value: "abc", ID: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	payload, sig, _ := strings.Cut(cursor, ".")
+	tampered, err := signer.Encode(testCursor{Value: "abc", ID: 2})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tamperedPayload, _, _ := strings.Cut(tampered, ".")
+
+	otherCursor, err := NewSigner([]byte("other secret")).Encode(testCursor{Value: "abc", ID: 1})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "Empty cursor", cursor: ""},
+		{name: "Missing signature", cursor: payload},
+		{name: "Invalid encoding", cursor: "!!!." + sig},
+		{name: "Tampered payload", cursor: tamperedPayload + "." + sig},
+		{name: "Signed with other key", cursor: otherCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testCursor
+			err := signer.Decode(tt.cursor, &got)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
@@ -0,0 +1,532 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396)
+// and JSON Patch (RFC 6902) documents to JSON documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch document")
+	ErrTestFailed   = errors.New("test operation failed")
+)
+
+// Patch modifies a JSON document
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+	// Members returns the top level members of the document the patch
+	// modifies, an empty name means the whole document is replaced
+	Members() ([]string, error)
+}
+
+// MergePatch is a JSON Merge Patch document. Members set to null
+// are removed from the target, absent members are left untouched.
+type MergePatch []byte
+
+func (p MergePatch) Apply(doc []byte) ([]byte, error) {
+	patch, err := decode(p)
+	if err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(target, patch))
+}
+
+func (p MergePatch) Members() ([]string, error) {
+	patch, err := decode(p)
+	if err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return []string{""}, nil
+	}
+
+	members := make([]string, 0, len(patchObj))
+	for name := range patchObj {
+		members = append(members, name)
+	}
+
+	slices.Sort(members)
+
+	return members, nil
+}
+
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+
+	return targetObj
+}
+
+// JSON Patch operations
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch is a JSON Patch document, a list of operations
+// applied in order. The patch fails as a whole if any operation fails.
+type JSONPatch []Operation
+
+// DecodeJSONPatch decodes and validates a JSON Patch document
+func DecodeJSONPatch(b []byte) (JSONPatch, error) {
+	var patch JSONPatch
+	if err := json.Unmarshal(b, &patch); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	for i, op := range patch {
+		switch op.Op {
+		case OpAdd, OpReplace, OpTest:
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d is missing a value", ErrInvalidPatch, i)
+			}
+
+		case OpMove, OpCopy:
+			if _, err := parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d has an invalid from", ErrInvalidPatch, i)
+			}
+
+		case OpRemove:
+
+		default:
+			return nil, fmt.Errorf("%w: operation %d has an unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d has an invalid path", ErrInvalidPatch, i)
+		}
+	}
+
+	return patch, nil
+}
+
+func (p JSONPatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range p {
+		target, err = applyOperation(target, op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func (p JSONPatch) Members() ([]string, error) {
+	var members []string
+	addMember := func(pointer string) error {
+		path, err := parsePointer(pointer)
+		if err != nil {
+			return err
+		}
+
+		member := ""
+		if len(path) != 0 {
+			member = path[0]
+		}
+
+		if !slices.Contains(members, member) {
+			members = append(members, member)
+		}
+
+		return nil
+	}
+
+	for _, op := range p {
+		switch op.Op {
+		case OpTest:
+			continue
+
+		case OpMove:
+			// Moving removes the source
+			if err := addMember(op.From); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := addMember(op.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	return members, nil
+}
+
+func applyOperation(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case OpAdd:
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, ErrInvalidPatch
+		}
+
+		return add(doc, path, value)
+
+	case OpRemove:
+		doc, _, err := remove(doc, path)
+		return doc, err
+
+	case OpReplace:
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, ErrInvalidPatch
+		}
+
+		doc, _, err = remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, value)
+
+	case OpMove:
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		// A location can not be moved into one of its children
+		if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+			return nil, fmt.Errorf("%w: can not move %q into itself", ErrInvalidPatch, op.From)
+		}
+
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, value)
+
+	case OpCopy:
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, deepCopy(value))
+
+	case OpTest:
+		want, err := decode(op.Value)
+		if err != nil {
+			return nil, ErrInvalidPatch
+		}
+
+		got, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equal(got, want) {
+			return nil, fmt.Errorf("%w: value at %q does not match", ErrTestFailed, op.Path)
+		}
+
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	key := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		container[key] = value
+		return doc, nil
+
+	case []any:
+		idx := len(container)
+		if key != "-" {
+			idx, err = arrayIndex(key, len(container)+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		arr := append(container[:idx:idx], append([]any{value}, container[idx:]...)...)
+
+		return set(doc, path[:len(path)-1], arr)
+	}
+
+	return nil, fmt.Errorf("%w: %q is not a container", ErrInvalidPatch, "/"+strings.Join(path[:len(path)-1], "/"))
+}
+
+// remove removes the value at path and returns it along with the modified document
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		value, ok := container[key]
+		if !ok {
+			return nil, nil, pathNotFound(path)
+		}
+
+		delete(container, key)
+
+		return doc, value, nil
+
+	case []any:
+		idx, err := arrayIndex(key, len(container))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := container[idx]
+		arr := append(container[:idx:idx], container[idx+1:]...)
+		doc, err = set(doc, path[:len(path)-1], arr)
+
+		return doc, value, err
+	}
+
+	return nil, nil, pathNotFound(path)
+}
+
+// set replaces the value at an existing path. Used for arrays,
+// as changing their length changes the slice itself.
+func set(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	key := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		container[key] = value
+
+	case []any:
+		idx, err := arrayIndex(key, len(container))
+		if err != nil {
+			return nil, err
+		}
+
+		container[idx] = value
+	}
+
+	return doc, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for i, key := range path {
+		switch container := doc.(type) {
+		case map[string]any:
+			value, ok := container[key]
+			if !ok {
+				return nil, pathNotFound(path[:i+1])
+			}
+
+			doc = value
+
+		case []any:
+			idx, err := arrayIndex(key, len(container))
+			if err != nil {
+				return nil, err
+			}
+
+			doc = container[idx]
+
+		default:
+			return nil, pathNotFound(path[:i+1])
+		}
+	}
+
+	return doc, nil
+}
+
+// arrayIndex parses an array index that must be lower than size
+func arrayIndex(key string, size int) (int, error) {
+	// Leading zeros are not allowed
+	if len(key) > 1 && key[0] == '0' {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, key)
+	}
+
+	idx, err := strconv.Atoi(key)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, key)
+	}
+
+	if idx >= size {
+		return 0, fmt.Errorf("%w: array index %d is out of bounds", ErrInvalidPatch, idx)
+	}
+
+	return idx, nil
+}
+
+// parsePointer parses a JSON Pointer (RFC 6901) into its reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func pathNotFound(path []string) error {
+	return fmt.Errorf("%w: path %q does not exist", ErrInvalidPatch, "/"+strings.Join(path, "/"))
+}
+
+// equal compares decoded JSON values, numbers are compared by their value
+func equal(a, b any) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		af, errA := av.Float64()
+		bf, errB := bv.Float64()
+
+		return errA == nil && errB == nil && af == bf
+
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+
+		for key, val := range av {
+			other, ok := bv[key]
+			if !ok || !equal(val, other) {
+				return false
+			}
+		}
+
+		return true
+
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return a == b
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		cp := make(map[string]any, len(v))
+		for key, val := range v {
+			cp[key] = deepCopy(val)
+		}
+
+		return cp
+
+	case []any:
+		cp := make([]any, len(v))
+		for i, val := range v {
+			cp[i] = deepCopy(val)
+		}
+
+		return cp
+	}
+
+	return value
+}
+
+func decode(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
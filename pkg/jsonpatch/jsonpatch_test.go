@@ -0,0 +1,289 @@
+package jsonpatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{
+			name:  "Replace member",
+			doc:   `{"a":"b"}`,
+			patch: `{"a":"c"}`,
+			want:  `{"a":"c"}`,
+		},
+		{
+			name:  "Add member",
+			doc:   `{"a":"b"}`,
+			patch: `{"b":"c"}`,
+			want:  `{"a":"b","b":"c"}`,
+		},
+		{
+			name:  "Null removes member",
+			doc:   `{"a":"b","b":"c"}`,
+			patch: `{"a":null}`,
+			want:  `{"b":"c"}`,
+		},
+		{
+			name:  "Array is replaced",
+			doc:   `{"a":["b"]}`,
+			patch: `{"a":["c","d"]}`,
+			want:  `{"a":["c","d"]}`,
+		},
+		{
+			name:  "Nested object is merged",
+			doc:   `{"a":{"b":"c","d":"e"}}`,
+			patch: `{"a":{"d":null,"f":"g"}}`,
+			want:  `{"a":{"b":"c","f":"g"}}`,
+		},
+		{
+			name:  "Non object patch replaces document",
+			doc:   `{"a":"b"}`,
+			patch: `["c"]`,
+			want:  `["c"]`,
+		},
+		{
+			name:  "Empty patch",
+			doc:   `{"a":"b"}`,
+			patch: `{}`,
+			want:  `{"a":"b"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch(tt.patch).Apply([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestMergePatch_Apply_Invalid(t *testing.T) {
+	_, err := MergePatch(`{"a":`).Apply([]byte(`{}`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestJSONPatch_Apply(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "Add member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			name:  "Add array element",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "Append array element",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"add","path":"/foo/-","value":"qux"}]`,
+			want:  `{"foo":["bar","qux"]}`,
+		},
+		{
+			name:  "Remove member",
+			doc:   `{"foo":"bar","baz":"qux"}`,
+			patch: `[{"op":"remove","path":"/baz"}]`,
+			want:  `{"foo":"bar"}`,
+		},
+		{
+			name:  "Remove array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "Replace member with null",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/foo","value":null}]`,
+			want:  `{"foo":null}`,
+		},
+		{
+			name:  "Move member",
+			doc:   `{"foo":{"bar":"baz"},"qux":{}}`,
+			patch: `[{"op":"move","from":"/foo/bar","path":"/qux/thud"}]`,
+			want:  `{"foo":{},"qux":{"thud":"baz"}}`,
+		},
+		{
+			name:  "Copy member",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"add","path":"/baz/-","value":"qux"}]`,
+			want:  `{"foo":["bar"],"baz":["bar","qux"]}`,
+		},
+		{
+			name:  "Escaped pointer",
+			doc:   `{"a/b":"c","m~n":"d"}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":"e"},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"a/b":"e"}`,
+		},
+		{
+			name:  "Test succeeds",
+			doc:   `{"foo":{"bar":1}}`,
+			patch: `[{"op":"test","path":"/foo","value":{"bar":1.0}},{"op":"add","path":"/baz","value":true}]`,
+			want:  `{"foo":{"bar":1},"baz":true}`,
+		},
+		{
+			name:    "Test fails",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"test","path":"/foo","value":"baz"}]`,
+			wantErr: ErrTestFailed,
+		},
+		{
+			name:    "Remove missing member",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"remove","path":"/baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "Add into missing parent",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"add","path":"/baz/qux","value":1}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "Array index out of bounds",
+			doc:     `{"foo":["bar"]}`,
+			patch:   `[{"op":"add","path":"/foo/2","value":"baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "Move into itself",
+			doc:     `{"foo":{"bar":"baz"}}`,
+			patch:   `[{"op":"move","from":"/foo","path":"/foo/bar"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := DecodeJSONPatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			got, err := patch.Apply([]byte(tt.doc))
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got error %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestDecodeJSONPatch_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{name: "Not an array", patch: `{"op":"add"}`},
+		{name: "Unknown op", patch: `[{"op":"merge","path":"/a"}]`},
+		{name: "Missing value", patch: `[{"op":"add","path":"/a"}]`},
+		{name: "Invalid path", patch: `[{"op":"remove","path":"a"}]`},
+		{name: "Invalid from", patch: `[{"op":"move","from":"a","path":"/a"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeJSONPatch([]byte(tt.patch))
+			assert.ErrorIs(t, err, ErrInvalidPatch)
+		})
+	}
+}
+
+func TestPatch_Members(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch Patch
+		want  []string
+	}{
+		{
+			name:  "Merge patch members",
+			patch: MergePatch(`{"b":{"c":1},"a":null}`),
+			want:  []string{"a", "b"},
+		},
+		{
+			name:  "Merge patch replacing the document",
+			patch: MergePatch(`["a"]`),
+			want:  []string{""},
+		},
+		{
+			name: "JSON patch paths",
+			patch: JSONPatch{
+				{Op: OpReplace, Path: "/a/b"},
+				{Op: OpAdd, Path: "/c/-"},
+				{Op: OpRemove, Path: "/a"},
+			},
+			want: []string{"a", "c"},
+		},
+		{
+			name: "Move source is modified",
+			patch: JSONPatch{
+				{Op: OpMove, From: "/a", Path: "/b"},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "Copy source and test are not modified",
+			patch: JSONPatch{
+				{Op: OpTest, Path: "/c"},
+				{Op: OpCopy, From: "/a", Path: "/b"},
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "Root path",
+			patch: JSONPatch{
+				{Op: OpReplace, Path: ""},
+			},
+			want: []string{""},
+		},
+		{
+			name: "Escaped member",
+			patch: JSONPatch{
+				{Op: OpReplace, Path: "/a~1b"},
+			},
+			want: []string{"a/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.patch.Members()
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := MergePatch(`{`).Members()
+	assert.True(t, errors.Is(err, ErrInvalidPatch))
+}
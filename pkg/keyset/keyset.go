@@ -0,0 +1,302 @@
+// Package keyset loads the keys tokens are signed and verified with,
+// and publishes their public parts as a JSON Web Key Set (RFC 7517).
+package keyset
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// minRSABits is the smallest RSA key accepted
+const minRSABits = 2048
+
+var (
+	ErrNoKey              = errors.New("no key found in PEM data")
+	ErrUnsupportedKey     = errors.New("unsupported key")
+	ErrUnknownActiveKey   = errors.New("active key does not exist")
+	ErrActiveKeyNotSigner = errors.New("active key has no private key")
+)
+
+// Key is a key identified by its kid. Keys loaded from a public key
+// can only verify tokens.
+type Key struct {
+	ID        string
+	Algorithm string
+	// signing is the private key or the HMAC secret,
+	// nil for verification only keys
+	signing   any
+	verifying any
+}
+
+// NewHMACKey creates a HS256 key. HMAC keys are secret,
+// so they are left out of the JSON Web Key Set.
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{ID: id, Algorithm: AlgHS256, signing: secret, verifying: secret}
+}
+
+// NewHMACVerificationKey creates a HS256 key that only verifies tokens,
+// to keep accepting the tokens of a secret that no longer signs them
+func NewHMACVerificationKey(id string, secret []byte) Key {
+	return Key{ID: id, Algorithm: AlgHS256, verifying: secret}
+}
+
+// ParsePEM parses the first private or public key found in PEM data.
+// RSA keys are used with RS256, P-256 keys with ES256 and Ed25519 keys with EdDSA.
+func ParsePEM(id string, data []byte) (Key, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return Key{}, ErrNoKey
+		}
+
+		var key any
+		var err error
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		default:
+			// Such as the EC PARAMETERS block written by openssl ecparam
+			continue
+		}
+
+		if err != nil {
+			return Key{}, fmt.Errorf("parsing %s error: %w", strings.ToLower(block.Type), err)
+		}
+
+		return newKey(id, key)
+	}
+}
+
+func newKey(id string, key any) (Key, error) {
+	var signing any
+	if signer, ok := key.(crypto.Signer); ok {
+		signing = key
+		key = signer.Public()
+	}
+
+	var alg string
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return Key{}, fmt.Errorf("%w: RSA key must be at least %d bits", ErrUnsupportedKey, minRSABits)
+		}
+
+		alg = AlgRS256
+
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return Key{}, fmt.Errorf("%w: only P-256 EC keys are supported", ErrUnsupportedKey)
+		}
+
+		alg = AlgES256
+
+	case ed25519.PublicKey:
+		alg = AlgEdDSA
+
+	default:
+		return Key{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+	}
+
+	return Key{ID: id, Algorithm: alg, signing: signing, verifying: key}, nil
+}
+
+// LoadDir loads the keys of the PEM files in dir, each file
+// holds one key and is named after its kid, as <kid>.pem
+func LoadDir(dir string) ([]Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(paths)
+
+	var keys []Key
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := ParsePEM(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("loading key %s error: %w", path, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// CanSign tells whether the key has a private key or secret
+func (k Key) CanSign() bool {
+	return k.signing != nil
+}
+
+// SigningKey returns the private key or the HMAC secret
+func (k Key) SigningKey() any {
+	return k.signing
+}
+
+// VerificationKey returns the public key or the HMAC secret
+func (k Key) VerificationKey() any {
+	return k.verifying
+}
+
+// JWK is a public key as a JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP keys (RFC 8037)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func encodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// encodeCoordinate encodes an EC coordinate padded to the size of the curve
+func encodeCoordinate(n *big.Int, size int) string {
+	b := make([]byte, size)
+	n.FillBytes(b)
+
+	return encodeBase64URL(b)
+}
+
+// JWK returns the public key as a JSON Web Key, false for HMAC keys
+func (k Key) JWK() (JWK, bool) {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+	switch pub := k.verifying.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeBase64URL(pub.N.Bytes())
+		jwk.E = encodeBase64URL(big.NewInt(int64(pub.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = encodeCoordinate(pub.X, size)
+		jwk.Y = encodeCoordinate(pub.Y, size)
+
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeBase64URL(pub)
+
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
+
+// Set is the keys tokens are verified with, and the active key
+// new tokens are signed with
+type Set struct {
+	keys   map[string]Key
+	ids    []string
+	active string
+}
+
+// NewSet creates a set signing with the key of activeId,
+// which must have a private key or secret
+func NewSet(keys []Key, activeId string) (Set, error) {
+	set := Set{keys: make(map[string]Key), active: activeId}
+	for _, key := range keys {
+		if _, ok := set.keys[key.ID]; ok {
+			return Set{}, fmt.Errorf("duplicate key %q", key.ID)
+		}
+
+		set.keys[key.ID] = key
+		set.ids = append(set.ids, key.ID)
+	}
+
+	active, ok := set.keys[activeId]
+	if !ok {
+		return Set{}, fmt.Errorf("%w: %q", ErrUnknownActiveKey, activeId)
+	}
+
+	if !active.CanSign() {
+		return Set{}, fmt.Errorf("%w: %q", ErrActiveKeyNotSigner, activeId)
+	}
+
+	return set, nil
+}
+
+// Active returns the key new tokens are signed with
+func (s Set) Active() Key {
+	return s.keys[s.active]
+}
+
+func (s Set) Lookup(id string) (Key, bool) {
+	key, ok := s.keys[id]
+	return key, ok
+}
+
+// Algorithms returns the algorithms of the keys in the set
+func (s Set) Algorithms() []string {
+	var algs []string
+	for _, id := range s.ids {
+		alg := s.keys[id].Algorithm
+		if !slices.Contains(algs, alg) {
+			algs = append(algs, alg)
+		}
+	}
+
+	return algs
+}
+
+// JWKS returns the public keys of the set, HMAC keys are left out
+func (s Set) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, id := range s.ids {
+		if jwk, ok := s.keys[id].JWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks
+}
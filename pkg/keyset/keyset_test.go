@@ -0,0 +1,315 @@
+package keyset
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePEM(t *testing.T, typ string, der []byte) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func pkcs8PEM(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return encodePEM(t, "PRIVATE KEY", der)
+}
+
+func pkixPEM(t *testing.T, key any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return encodePEM(t, "PUBLIC KEY", der)
+}
+
+func TestParsePEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ecParams := encodePEM(t, "EC PARAMETERS", []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07})
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantAlg     string
+		wantCanSign bool
+		wantErr     error
+	}{
+		{name: "rsa pkcs8", data: pkcs8PEM(t, rsaKey), wantAlg: AlgRS256, wantCanSign: true},
+		{name: "rsa pkcs1", data: encodePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), wantAlg: AlgRS256, wantCanSign: true},
+		{name: "rsa public", data: pkixPEM(t, &rsaKey.PublicKey), wantAlg: AlgRS256},
+		{name: "rsa pkcs1 public", data: encodePEM(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)), wantAlg: AlgRS256},
+		{name: "ec pkcs8", data: pkcs8PEM(t, ecKey), wantAlg: AlgES256, wantCanSign: true},
+		{name: "ec sec1 after parameters", data: append(ecParams, encodePEM(t, "EC PRIVATE KEY", ecDER)...), wantAlg: AlgES256, wantCanSign: true},
+		{name: "ec public", data: pkixPEM(t, &ecKey.PublicKey), wantAlg: AlgES256},
+		{name: "ed25519 pkcs8", data: pkcs8PEM(t, edKey), wantAlg: AlgEdDSA, wantCanSign: true},
+		{name: "ed25519 public", data: pkixPEM(t, edKey.Public()), wantAlg: AlgEdDSA},
+		{name: "small rsa key", data: pkcs8PEM(t, smallRSAKey), wantErr: ErrUnsupportedKey},
+		{name: "p-384 key", data: pkcs8PEM(t, p384Key), wantErr: ErrUnsupportedKey},
+		{name: "no pem block", data: []byte("not a key"), wantErr: ErrNoKey},
+		{name: "no key block", data: ecParams, wantErr: ErrNoKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePEM("kid", tt.data)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			assert.Equal(t, "kid", key.ID)
+			assert.Equal(t, tt.wantAlg, key.Algorithm)
+			assert.Equal(t, tt.wantCanSign, key.CanSign())
+			assert.NotNil(t, key.VerificationKey())
+		})
+	}
+}
+
+func TestKey_JWK(t *testing.T) {
+	// RFC 8037 appendix A.2
+	d, err := base64.RawURLEncoding.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	key, err := newKey("ed", ed25519.NewKeyFromSeed(d))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	jwk, ok := key.JWK()
+	if !ok {
+		t.Fatal("key has no JWK")
+	}
+	assert.Equal(t, JWK{
+		Kty: "OKP",
+		Kid: "ed",
+		Use: "sig",
+		Alg: AlgEdDSA,
+		Crv: "Ed25519",
+		X:   "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+	}, jwk)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	key, err = newKey("ec", ecKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	jwk, ok = key.JWK()
+	if !ok {
+		t.Fatal("key has no JWK")
+	}
+	assert.Equal(t, "EC", jwk.Kty)
+	assert.Equal(t, "P-256", jwk.Crv)
+	// Coordinates are always the full 32 bytes
+	assert.Len(t, jwk.X, 43)
+	assert.Len(t, jwk.Y, 43)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	key, err = newKey("rsa", rsaKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	jwk, ok = key.JWK()
+	if !ok {
+		t.Fatal("key has no JWK")
+	}
+	assert.Equal(t, "RSA", jwk.Kty)
+	assert.Equal(t, "AQAB", jwk.E)
+
+	_, ok = NewHMACKey("hs", []byte("secret")).JWK()
+	assert.False(t, ok)
+}
+
+func TestNewSet(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	signer, err := newKey("signer", edKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	verifier, err := newKey("verifier", edKey.Public())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	keys := []Key{signer, verifier, NewHMACKey("hs", []byte("secret")), NewHMACVerificationKey("old-hs", []byte("old"))}
+
+	tests := []struct {
+		name     string
+		keys     []Key
+		activeId string
+		wantErr  error
+	}{
+		{name: "private key", keys: keys, activeId: "signer"},
+		{name: "hmac key", keys: keys, activeId: "hs"},
+		{name: "public key", keys: keys, activeId: "verifier", wantErr: ErrActiveKeyNotSigner},
+		{name: "hmac verification key", keys: keys, activeId: "old-hs", wantErr: ErrActiveKeyNotSigner},
+		{name: "unknown key", keys: keys, activeId: "unknown", wantErr: ErrUnknownActiveKey},
+		{name: "no keys", activeId: "signer", wantErr: ErrUnknownActiveKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := NewSet(tt.keys, tt.activeId)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			assert.Equal(t, tt.activeId, set.Active().ID)
+		})
+	}
+
+	_, err = NewSet([]Key{signer, signer}, "signer")
+	assert.Error(t, err)
+}
+
+func TestSet(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	edSigner, err := newKey("ed", edKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ecVerifier, err := newKey("ec", &ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	set, err := NewSet([]Key{edSigner, ecVerifier, NewHMACKey("hs", []byte("secret"))}, "ed")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, []string{AlgEdDSA, AlgES256, AlgHS256}, set.Algorithms())
+
+	key, ok := set.Lookup("ec")
+	assert.True(t, ok)
+	assert.Equal(t, AlgES256, key.Algorithm)
+
+	_, ok = set.Lookup("unknown")
+	assert.False(t, ok)
+
+	jwks := set.JWKS()
+	if !assert.Len(t, jwks.Keys, 2) {
+		return
+	}
+	assert.Equal(t, "ed", jwks.Keys[0].Kid)
+	assert.Equal(t, "ec", jwks.Keys[1].Kid)
+
+	hmacOnly, err := NewSet([]Key{NewHMACKey("hs", []byte("secret"))}, "hs")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Empty(t, hmacOnly.JWKS().Keys)
+	assert.NotNil(t, hmacOnly.JWKS().Keys)
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "2025-10.pem"), pkcs8PEM(t, edKey), 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "2025-09.pem"), pkixPEM(t, &ecKey.PublicKey), 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	keys, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !assert.Len(t, keys, 2) {
+		return
+	}
+	assert.Equal(t, "2025-09", keys[0].ID)
+	assert.Equal(t, AlgES256, keys[0].Algorithm)
+	assert.False(t, keys[0].CanSign())
+	assert.Equal(t, "2025-10", keys[1].ID)
+	assert.Equal(t, AlgEdDSA, keys[1].Algorithm)
+	assert.True(t, keys[1].CanSign())
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+	_, err = LoadDir(dir)
+	assert.ErrorIs(t, err, ErrNoKey)
+}
@@ -0,0 +1,105 @@
+// Package rank generates lexicographic ranks, strings that are ordered
+// by byte comparison and between which another rank can always be made,
+// so an item can be moved without renumbering the others.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// digits are the characters of a rank, in ascending byte order
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var (
+	ErrInvalidRank  = errors.New("invalid rank")
+	ErrInvalidOrder = errors.New("rank a must be before rank b")
+)
+
+// Between returns a rank ordered after a and before b.
+// An empty a means the start of the order and an empty b means its end,
+// so Between("", "") returns the first rank.
+func Between(a, b string) (string, error) {
+	if !isValid(a) || !isValid(b) {
+		return "", ErrInvalidRank
+	}
+
+	if b != "" && a >= b {
+		return "", ErrInvalidOrder
+	}
+
+	return between(a, b), nil
+}
+
+// isValid reports whether r only has rank digits and does not end with
+// the lowest digit, as no rank could be made between r and r + "0"
+func isValid(r string) bool {
+	for i := 0; i < len(r); i++ {
+		if strings.IndexByte(digits, r[i]) == -1 {
+			return false
+		}
+	}
+
+	return r == "" || r[len(r)-1] != digits[0]
+}
+
+func between(a, b string) string {
+	// Keep the common prefix, a is padded with the lowest digit
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+
+		if n > 0 {
+			return b[:n] + between(suffix(a, n), b[n:])
+		}
+	}
+
+	lo := 0
+	if a != "" {
+		lo = strings.IndexByte(digits, a[0])
+	}
+
+	hi := len(digits)
+	if b != "" {
+		hi = strings.IndexByte(digits, b[0])
+	}
+
+	if hi-lo > 1 {
+		switch {
+		// Step next to the bound on an open end, so ranks appended
+		// one after another grow slowly
+		case a != "" && b == "":
+			return string(digits[lo+1])
+
+		case a == "" && b != "":
+			return string(digits[hi-1])
+		}
+
+		return string(digits[(lo+hi)/2])
+	}
+
+	// The first digits are adjacent, a shorter b is still after a
+	if len(b) > 1 {
+		return b[:1]
+	}
+
+	return string(digits[lo]) + between(suffix(a, 1), "")
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+
+	return digits[0]
+}
+
+func suffix(s string, i int) string {
+	if i < len(s) {
+		return s[i:]
+	}
+
+	return ""
+}
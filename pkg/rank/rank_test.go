@@ -0,0 +1,88 @@
+package rank
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{name: "First rank", a: "", b: "", want: "V"},
+		{name: "After", a: "V", b: "", want: "W"},
+		{name: "Before", a: "", b: "V", want: "U"},
+		{name: "Middle", a: "A", b: "C", want: "B"},
+		{name: "Adjacent digits", a: "A", b: "B", want: "AV"},
+		{name: "Common prefix", a: "AB", b: "AD", want: "AC"},
+		{name: "Prefix of b", a: "A", b: "A5", want: "A4"},
+		{name: "Longer b", a: "A", b: "BV", want: "B"},
+		{name: "After last digit", a: "z", b: "", want: "zV"},
+		{name: "Before lowest", a: "", b: "1", want: "0V"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Between(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBetween_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       string
+		b       string
+		wantErr error
+	}{
+		{name: "Invalid character", a: "A-", b: "", wantErr: ErrInvalidRank},
+		{name: "Trailing lowest digit", a: "A0", b: "", wantErr: ErrInvalidRank},
+		{name: "Equal ranks", a: "A", b: "A", wantErr: ErrInvalidOrder},
+		{name: "Reversed ranks", a: "B", b: "A", wantErr: ErrInvalidOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Between(tt.a, tt.b)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestBetween_RandomInserts(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	ranks := []string{}
+	for i := 0; i < 1000; i++ {
+		pos := rnd.Intn(len(ranks) + 1)
+
+		var a, b string
+		if pos > 0 {
+			a = ranks[pos-1]
+		}
+
+		if pos < len(ranks) {
+			b = ranks[pos]
+		}
+
+		r, err := Between(a, b)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if !isValid(r) || r <= a || (b != "" && r >= b) {
+			t.Fatalf("rank %q is not between %q and %q", r, a, b)
+		}
+
+		ranks = append(ranks[:pos], append([]string{r}, ranks[pos:]...)...)
+	}
+}
@@ -0,0 +1,497 @@
+// Package rrule parses iCalendar recurrence rules (RFC 5545) and computes
+// their occurrences. It supports the FREQ, INTERVAL, COUNT, UNTIL, BYDAY,
+// BYMONTHDAY and BYMONTH rule parts with DAILY, WEEKLY, MONTHLY and
+// YEARLY frequencies, which covers the usual task schedules.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxPeriods bounds the periods walked to find an occurrence,
+// so a rule that never matches does not loop forever
+const maxPeriods = 10000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY value. N is the nth occurrence of the weekday
+// within the month, counted from the end when negative. Zero means
+// every occurrence.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+func (wn WeekdayNum) String() string {
+	for name, day := range weekdays {
+		if day == wn.Weekday {
+			if wn.N != 0 {
+				return strconv.Itoa(wn.N) + name
+			}
+
+			return name
+		}
+	}
+
+	return ""
+}
+
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+// Parse parses a recurrence rule such as "FREQ=WEEKLY;BYDAY=MO,WE".
+// The "RRULE:" prefix is optional.
+func Parse(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return Rule{}, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	rule := Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || value == "" {
+			return Rule{}, fmt.Errorf("%w: invalid part %q", ErrInvalidRule, part)
+		}
+
+		if seen[name] {
+			return Rule{}, fmt.Errorf("%w: duplicate part %s", ErrInvalidRule, name)
+		}
+
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = errors.New("unsupported frequency")
+			}
+
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err == nil && rule.Interval < 1 {
+				err = errors.New("must be positive")
+			}
+
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("must be positive")
+			}
+
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(value)
+			rule.Until = &until
+
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(value, func(n int) bool { return n != 0 && n >= -31 && n <= 31 })
+
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(value, func(n int) bool { return n >= 1 && n <= 12 })
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+
+		case "WKST":
+			// Weeks always start on Monday, the default week start
+			if strings.ToUpper(value) != "MO" {
+				err = errors.New("only MO is supported")
+			}
+
+		default:
+			err = errors.New("unsupported part")
+		}
+
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %s: %v", ErrInvalidRule, name, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+
+	if rule.Count != 0 && rule.Until != nil {
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL can not be used together", ErrInvalidRule)
+	}
+
+	// Ordinal weekdays only make sense within a month or a year
+	if rule.Freq == Daily || rule.Freq == Weekly {
+		for _, wn := range rule.ByDay {
+			if wn.N != 0 {
+				return Rule{}, fmt.Errorf("%w: BYDAY can not have an ordinal with %s frequency", ErrInvalidRule, rule.Freq)
+			}
+		}
+	}
+
+	if rule.Freq == Weekly && len(rule.ByMonthDay) != 0 {
+		return Rule{}, fmt.Errorf("%w: BYMONTHDAY can not be used with WEEKLY frequency", ErrInvalidRule)
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	// A date includes the occurrences of the whole day
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+
+	return time.Time{}, errors.New("invalid date")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToUpper(item)
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+
+		day, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+
+		wn := WeekdayNum{Weekday: day}
+		if num := item[:len(item)-2]; num != "" {
+			n, err := strconv.Atoi(num)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid weekday %q", item)
+			}
+
+			wn.N = n
+		}
+
+		days = append(days, wn)
+	}
+
+	return days, nil
+}
+
+func parseInts(value string, valid func(n int) bool) ([]int, error) {
+	var nums []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || !valid(n) {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+
+		nums = append(nums, n)
+	}
+
+	return nums, nil
+}
+
+// String formats the rule back to its RFC 5545 form
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if r.Count != 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+
+	if len(r.ByDay) != 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wn := range r.ByDay {
+			days[i] = wn.String()
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonthDay) != 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonth) != 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = strconv.Itoa(int(m))
+		}
+
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the rule starting at dtstart
+// that is after t. dtstart is always the first occurrence.
+// False is returned if the rule has no more occurrences.
+func (r Rule) Next(dtstart, t time.Time) (time.Time, bool) {
+	count := 0
+	next, found := time.Time{}, false
+	r.iterate(dtstart, func(occurrence time.Time) bool {
+		count++
+		if r.Count != 0 && count > r.Count {
+			return false
+		}
+
+		if r.Until != nil && occurrence.After(*r.Until) {
+			return false
+		}
+
+		if occurrence.After(t) {
+			next, found = occurrence, true
+			return false
+		}
+
+		return true
+	})
+
+	return next, found
+}
+
+// iterate calls fn with the occurrences in order until fn returns false
+func (r Rule) iterate(dtstart time.Time, fn func(t time.Time) bool) {
+	if !fn(dtstart) {
+		return
+	}
+
+	for period := 0; period < maxPeriods; period++ {
+		candidates := r.periodCandidates(dtstart, period)
+		slices.SortFunc(candidates, func(a, b time.Time) int { return a.Compare(b) })
+		for _, candidate := range candidates {
+			if !candidate.After(dtstart) {
+				continue
+			}
+
+			if !fn(candidate) {
+				return
+			}
+		}
+	}
+}
+
+// periodCandidates returns the occurrences within the nth period of the rule
+func (r Rule) periodCandidates(dtstart time.Time, n int) []time.Time {
+	step := n * r.Interval
+	hour, min, sec := dtstart.Clock()
+	loc := dtstart.Location()
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, dtstart.Nanosecond(), loc)
+	}
+
+	var candidates []time.Time
+	switch r.Freq {
+	case Daily:
+		day := dtstart.AddDate(0, 0, step)
+		if r.matchesMonth(day) && r.matchesWeekday(day) && r.matchesMonthDay(day) {
+			candidates = append(candidates, day)
+		}
+
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			if day := dtstart.AddDate(0, 0, 7*step); r.matchesMonth(day) {
+				candidates = append(candidates, day)
+			}
+
+			break
+		}
+
+		// Weeks start on Monday
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		monday := dtstart.AddDate(0, 0, 7*step-offset)
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			if r.matchesMonth(day) && r.matchesWeekday(day) {
+				candidates = append(candidates, day)
+			}
+		}
+
+	case Monthly:
+		first := date(dtstart.Year(), dtstart.Month()+time.Month(step), 1)
+		if r.matchesMonth(first) {
+			candidates = r.monthCandidates(first, dtstart.Day(), date)
+		}
+
+	case Yearly:
+		year := dtstart.Year() + step
+		months := r.ByMonth
+		if len(months) == 0 {
+			// Weekdays are within the whole year, ordinals counted in the year
+			if len(r.ByDay) != 0 {
+				candidates = r.yearCandidates(year, date)
+				break
+			}
+
+			if len(r.ByMonthDay) != 0 {
+				months = []time.Month{time.January, time.February, time.March, time.April, time.May, time.June,
+					time.July, time.August, time.September, time.October, time.November, time.December}
+			} else {
+				months = []time.Month{dtstart.Month()}
+			}
+		}
+
+		for _, month := range months {
+			candidates = append(candidates, r.monthCandidates(date(year, month, 1), dtstart.Day(), date)...)
+		}
+	}
+
+	return candidates
+}
+
+// monthCandidates returns the days of the month starting at first that match
+// BYMONTHDAY and BYDAY, or the same day as dtstart when neither is set
+func (r Rule) monthCandidates(first time.Time, startDay int, date func(year int, month time.Month, day int) time.Time) []time.Time {
+	year, month := first.Year(), first.Month()
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		// Months without the day are skipped
+		if startDay > daysInMonth {
+			return nil
+		}
+
+		return []time.Time{date(year, month, startDay)}
+	}
+
+	var candidates []time.Time
+	for day := 1; day <= daysInMonth; day++ {
+		t := date(year, month, day)
+		if len(r.ByMonthDay) != 0 && !r.matchesMonthDay(t) {
+			continue
+		}
+
+		if len(r.ByDay) != 0 && !r.matchesWeekdayInMonth(t, daysInMonth) {
+			continue
+		}
+
+		candidates = append(candidates, t)
+	}
+
+	return candidates
+}
+
+// yearCandidates returns the days of the year that match BYDAY,
+// and BYMONTHDAY if set
+func (r Rule) yearCandidates(year int, date func(year int, month time.Month, day int) time.Time) []time.Time {
+	first := date(year, time.January, 1)
+	daysInYear := date(year, time.December, 31).YearDay()
+
+	var candidates []time.Time
+	for t := first; t.Year() == year; t = t.AddDate(0, 0, 1) {
+		if !r.matchesMonthDay(t) {
+			continue
+		}
+
+		nth := (t.YearDay()-1)/7 + 1
+		nthFromEnd := -((daysInYear-t.YearDay())/7 + 1)
+		for _, wn := range r.ByDay {
+			if wn.Weekday == t.Weekday() && (wn.N == 0 || wn.N == nth || wn.N == nthFromEnd) {
+				candidates = append(candidates, t)
+				break
+			}
+		}
+	}
+
+	return candidates
+}
+
+func (r Rule) matchesMonth(t time.Time) bool {
+	return len(r.ByMonth) == 0 || slices.Contains(r.ByMonth, t.Month())
+}
+
+func (r Rule) matchesWeekday(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	for _, wn := range r.ByDay {
+		if wn.Weekday == t.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Rule) matchesWeekdayInMonth(t time.Time, daysInMonth int) bool {
+	nth := (t.Day()-1)/7 + 1
+	nthFromEnd := -((daysInMonth-t.Day())/7 + 1)
+	for _, wn := range r.ByDay {
+		if wn.Weekday != t.Weekday() {
+			continue
+		}
+
+		if wn.N == 0 || wn.N == nth || wn.N == nthFromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Rule) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	for _, day := range r.ByMonthDay {
+		if day == t.Day() || (day < 0 && daysInMonth+day+1 == t.Day()) {
+			return true
+		}
+	}
+
+	return false
+}
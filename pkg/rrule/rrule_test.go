@@ -0,0 +1,256 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Daily", rule: "FREQ=DAILY", want: "FREQ=DAILY"},
+		{name: "With prefix", rule: "RRULE:FREQ=WEEKLY;BYDAY=MO,FR", want: "FREQ=WEEKLY;BYDAY=MO,FR"},
+		{name: "Lower case", rule: "freq=monthly;byday=-1fr", want: "FREQ=MONTHLY;BYDAY=-1FR"},
+		{name: "Interval and count", rule: "FREQ=DAILY;INTERVAL=2;COUNT=5", want: "FREQ=DAILY;INTERVAL=2;COUNT=5"},
+		{name: "Until date", rule: "FREQ=DAILY;UNTIL=20251231", want: "FREQ=DAILY;UNTIL=20251231T235959Z"},
+		{name: "Month days", rule: "FREQ=MONTHLY;BYMONTHDAY=1,-1", want: "FREQ=MONTHLY;BYMONTHDAY=1,-1"},
+		{name: "Months", rule: "FREQ=YEARLY;BYMONTH=3,9", want: "FREQ=YEARLY;BYMONTH=3,9"},
+		{name: "Empty", rule: "", wantErr: true},
+		{name: "Missing frequency", rule: "INTERVAL=2", wantErr: true},
+		{name: "Unsupported frequency", rule: "FREQ=HOURLY", wantErr: true},
+		{name: "Invalid interval", rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "Count and until", rule: "FREQ=DAILY;COUNT=2;UNTIL=20251231", wantErr: true},
+		{name: "Invalid weekday", rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{name: "Ordinal weekday on weekly", rule: "FREQ=WEEKLY;BYDAY=1MO", wantErr: true},
+		{name: "Invalid month day", rule: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{name: "Duplicate part", rule: "FREQ=DAILY;FREQ=WEEKLY", wantErr: true},
+		{name: "Unsupported part", rule: "FREQ=DAILY;BYHOUR=9", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidRule))
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			assert.Equal(t, tt.want, rule.String())
+		})
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		after   time.Time
+		want    []time.Time
+		// ends is set when want holds all the remaining occurrences
+		ends bool
+	}{
+		{
+			name:    "Daily",
+			rule:    "FREQ=DAILY",
+			dtstart: date(2025, 10, 1),
+			after:   date(2025, 10, 1),
+			want:    []time.Time{date(2025, 10, 2), date(2025, 10, 3), date(2025, 10, 4)},
+		},
+		{
+			name:    "Every other day",
+			rule:    "FREQ=DAILY;INTERVAL=2",
+			dtstart: date(2025, 10, 1),
+			after:   date(2025, 10, 2),
+			want:    []time.Time{date(2025, 10, 3), date(2025, 10, 5)},
+		},
+		{
+			name:    "Weekdays",
+			rule:    "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			dtstart: date(2025, 10, 9),
+			after:   date(2025, 10, 9),
+			want:    []time.Time{date(2025, 10, 10), date(2025, 10, 13), date(2025, 10, 14)},
+		},
+		{
+			name:    "Weekly",
+			rule:    "FREQ=WEEKLY",
+			dtstart: date(2025, 10, 1),
+			after:   date(2025, 10, 1),
+			want:    []time.Time{date(2025, 10, 8), date(2025, 10, 15)},
+		},
+		{
+			name:    "Weekly on days",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,FR",
+			dtstart: date(2025, 10, 1),
+			after:   date(2025, 10, 1),
+			want:    []time.Time{date(2025, 10, 3), date(2025, 10, 6), date(2025, 10, 10)},
+		},
+		{
+			name:    "Every other week on days",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH",
+			dtstart: date(2025, 10, 7),
+			after:   date(2025, 10, 7),
+			want:    []time.Time{date(2025, 10, 9), date(2025, 10, 21), date(2025, 10, 23)},
+		},
+		{
+			name:    "Monthly",
+			rule:    "FREQ=MONTHLY",
+			dtstart: date(2025, 1, 15),
+			after:   date(2025, 1, 15),
+			want:    []time.Time{date(2025, 2, 15), date(2025, 3, 15)},
+		},
+		{
+			name:    "Monthly skips short months",
+			rule:    "FREQ=MONTHLY",
+			dtstart: date(2025, 1, 31),
+			after:   date(2025, 1, 31),
+			want:    []time.Time{date(2025, 3, 31), date(2025, 5, 31)},
+		},
+		{
+			name:    "Last day of month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: date(2025, 1, 31),
+			after:   date(2025, 1, 31),
+			want:    []time.Time{date(2025, 2, 28), date(2025, 3, 31)},
+		},
+		{
+			name:    "Last friday of month",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart: date(2025, 10, 31),
+			after:   date(2025, 10, 31),
+			want:    []time.Time{date(2025, 11, 28), date(2025, 12, 26)},
+		},
+		{
+			name:    "Second monday of month",
+			rule:    "FREQ=MONTHLY;BYDAY=2MO",
+			dtstart: date(2025, 10, 13),
+			after:   date(2025, 10, 13),
+			want:    []time.Time{date(2025, 11, 10), date(2025, 12, 8)},
+		},
+		{
+			name:    "Yearly",
+			rule:    "FREQ=YEARLY",
+			dtstart: date(2024, 2, 29),
+			after:   date(2024, 2, 29),
+			want:    []time.Time{date(2028, 2, 29)},
+		},
+		{
+			name:    "Yearly in months",
+			rule:    "FREQ=YEARLY;BYMONTH=3,9",
+			dtstart: date(2025, 3, 1),
+			after:   date(2025, 3, 1),
+			want:    []time.Time{date(2025, 9, 1), date(2026, 3, 1)},
+		},
+		{
+			name:    "Yearly on weekday",
+			rule:    "FREQ=YEARLY;BYDAY=MO",
+			dtstart: date(2026, 1, 5),
+			after:   date(2026, 1, 26),
+			want:    []time.Time{date(2026, 2, 2), date(2026, 2, 9)},
+		},
+		{
+			name:    "Yearly on weekday crosses the year",
+			rule:    "FREQ=YEARLY;BYDAY=MO",
+			dtstart: date(2026, 1, 5),
+			after:   date(2026, 12, 28),
+			want:    []time.Time{date(2027, 1, 4)},
+		},
+		{
+			name:    "Yearly on nth weekday of the year",
+			rule:    "FREQ=YEARLY;BYDAY=20MO,-1FR",
+			dtstart: date(2026, 1, 5),
+			after:   date(2026, 1, 5),
+			want:    []time.Time{date(2026, 5, 18), date(2026, 12, 25), date(2027, 5, 17)},
+		},
+		{
+			name:    "Yearly on month day",
+			rule:    "FREQ=YEARLY;BYMONTHDAY=15",
+			dtstart: date(2026, 1, 15),
+			after:   date(2026, 1, 15),
+			want:    []time.Time{date(2026, 2, 15), date(2026, 3, 15)},
+		},
+		{
+			name:    "Weekly in months",
+			rule:    "FREQ=WEEKLY;BYMONTH=6",
+			dtstart: date(2026, 5, 27),
+			after:   date(2026, 5, 27),
+			want:    []time.Time{date(2026, 6, 3), date(2026, 6, 10), date(2026, 6, 17), date(2026, 6, 24), date(2027, 6, 2)},
+		},
+		{
+			name:    "Count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: date(2025, 10, 1),
+			after:   date(2025, 10, 1),
+			want:    []time.Time{date(2025, 10, 2), date(2025, 10, 3)},
+			ends:    true,
+		},
+		{
+			name:    "Until",
+			rule:    "FREQ=WEEKLY;UNTIL=20251015T090000Z",
+			dtstart: date(2025, 10, 1),
+			after:   date(2025, 10, 1),
+			want:    []time.Time{date(2025, 10, 8), date(2025, 10, 15)},
+			ends:    true,
+		},
+		{
+			name:    "Until date includes the day",
+			rule:    "FREQ=DAILY;UNTIL=20260106",
+			dtstart: date(2026, 1, 4),
+			after:   date(2026, 1, 4),
+			want:    []time.Time{date(2026, 1, 5), date(2026, 1, 6)},
+			ends:    true,
+		},
+		{
+			name:    "After a later time",
+			rule:    "FREQ=WEEKLY",
+			dtstart: date(2025, 10, 1),
+			after:   date(2025, 10, 20),
+			want:    []time.Time{date(2025, 10, 22)},
+		},
+		{
+			name:    "Never matches",
+			rule:    "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			dtstart: date(2025, 1, 1),
+			after:   date(2025, 1, 1),
+			ends:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			var got []time.Time
+			after := tt.after
+			for {
+				next, ok := rule.Next(tt.dtstart, after)
+				if !ok || (!tt.ends && len(got) == len(tt.want)) || len(got) > len(tt.want) {
+					break
+				}
+
+				got = append(got, next)
+				after = next
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
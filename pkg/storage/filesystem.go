@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FilesystemStore stores blobs as files under a directory
+type FilesystemStore struct {
+	dir string
+}
+
+// NewFilesystemStore creates a store in dir, creating the directory if needed
+func NewFilesystemStore(dir string) (FilesystemStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return FilesystemStore{}, fmt.Errorf("creating blob directory: %w", err)
+	}
+
+	return FilesystemStore{dir: dir}, nil
+}
+
+func (fs FilesystemStore) path(key string) string {
+	return filepath.Join(fs.dir, filepath.FromSlash(key))
+}
+
+func (fs FilesystemStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	dst := fs.path(key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+
+	// The blob is written to a temporary file first, so readers
+	// never see a partially written blob
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(r, size))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if n != size {
+		return fmt.Errorf("blob is %d bytes, expected %d: %w", n, size, io.ErrUnexpectedEOF)
+	}
+
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (fs FilesystemStore) Open(ctx context.Context, key string) (Blob, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fs.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return fileBlob{File: f, size: info.Size()}, nil
+}
+
+func (fs FilesystemStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(fs.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+type fileBlob struct {
+	*os.File
+	size int64
+}
+
+func (b fileBlob) Size() int64 {
+	return b.size
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type S3Config struct {
+	// Endpoint is the base URL of the storage, such as https://s3.us-east-1.amazonaws.com
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store stores blobs as objects of an S3 compatible storage bucket.
+// Objects are addressed path style, which is supported by most S3
+// compatible storages.
+type S3Store struct {
+	endpoint *url.URL
+	bucket   string
+	creds    credentials
+	client   *http.Client
+}
+
+func NewS3Store(cfg S3Config, client *http.Client) (S3Store, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return S3Store{}, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+
+	if cfg.Bucket == "" {
+		return S3Store{}, errors.New("s3 bucket is required")
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return S3Store{
+		endpoint: endpoint,
+		bucket:   cfg.Bucket,
+		creds: credentials{
+			accessKey: cfg.AccessKey,
+			secretKey: cfg.SecretKey,
+			region:    cfg.Region,
+			service:   "s3",
+		},
+		client: client,
+	}, nil
+}
+
+func (s S3Store) objectURL(key string) string {
+	u := *s.endpoint
+	segments := []string{uriEncode(s.bucket)}
+	for _, segment := range strings.Split(key, "/") {
+		segments = append(segments, uriEncode(segment))
+	}
+
+	u.RawPath = strings.TrimSuffix(u.Path, "/") + "/" + strings.Join(segments, "/")
+	u.Path, _ = url.PathUnescape(u.RawPath)
+
+	return u.String()
+}
+
+func (s S3Store) do(ctx context.Context, method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	payloadHash := emptyPayload
+	if body != nil {
+		req.ContentLength = size
+		payloadHash = unsignedPayload
+	}
+
+	s.creds.sign(req, payloadHash, time.Now())
+
+	return s.client.Do(req)
+}
+
+// statusError turns an unexpected response into an error, including the
+// start of the body as S3 describes errors there
+func statusError(method string, res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return fmt.Errorf("s3 %s: unexpected status %d: %s", method, res.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func (s S3Store) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodPut, key, io.LimitReader(r, size), size, nil)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return statusError(http.MethodPut, res)
+	}
+
+	return nil
+}
+
+func (s S3Store) Open(ctx context.Context, key string) (Blob, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	res, err := s.do(ctx, http.MethodHead, key, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, statusError(http.MethodHead, res)
+	}
+
+	return &s3Blob{ctx: ctx, store: s, key: key, size: res.ContentLength}, nil
+}
+
+func (s S3Store) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	res, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+
+	return statusError(http.MethodDelete, res)
+}
+
+// s3Blob reads an object lazily. The object is fetched from the current
+// offset on the first read, so seeking before reading only fetches the
+// requested part of the object.
+type s3Blob struct {
+	ctx    context.Context
+	store  S3Store
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (b *s3Blob) Size() int64 {
+	return b.size
+}
+
+func (b *s3Blob) Read(p []byte) (int, error) {
+	if b.offset >= b.size {
+		return 0, io.EOF
+	}
+
+	if b.body == nil {
+		header := http.Header{"Range": {"bytes=" + strconv.FormatInt(b.offset, 10) + "-"}}
+		res, err := b.store.do(b.ctx, http.MethodGet, b.key, nil, 0, header)
+		if err != nil {
+			return 0, err
+		}
+
+		if res.StatusCode != http.StatusPartialContent && res.StatusCode != http.StatusOK {
+			defer res.Body.Close()
+			return 0, statusError(http.MethodGet, res)
+		}
+
+		// A storage ignoring the range sends the whole object
+		if res.StatusCode == http.StatusOK && b.offset != 0 {
+			if _, err := io.CopyN(io.Discard, res.Body, b.offset); err != nil {
+				res.Body.Close()
+				return 0, err
+			}
+		}
+
+		b.body = res.Body
+	}
+
+	n, err := b.body.Read(p)
+	b.offset += int64(n)
+
+	return n, err
+}
+
+func (b *s3Blob) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.offset
+	case io.SeekEnd:
+		offset += b.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if offset != b.offset && b.body != nil {
+		b.body.Close()
+		b.body = nil
+	}
+
+	b.offset = offset
+
+	return offset, nil
+}
+
+func (b *s3Blob) Close() error {
+	if b.body == nil {
+		return nil
+	}
+
+	err := b.body.Close()
+	b.body = nil
+
+	return err
+}
@@ -0,0 +1,43 @@
+// Package storage stores binary blobs, such as uploaded files,
+// on the local filesystem or in an S3 compatible object storage.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore stores blobs under slash separated keys
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing the existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Open opens the blob under key for reading, ErrNotFound is returned if it does not exist
+	Open(ctx context.Context, key string) (Blob, error)
+	// Delete deletes the blob under key, deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Blob is the content of a stored blob. Seeking allows reading a part of it,
+// such as for HTTP range requests.
+type Blob interface {
+	io.ReadSeekCloser
+	Size() int64
+}
+
+// validateKey makes sure a key can not point outside of the store
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return nil
+}
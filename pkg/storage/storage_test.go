@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+	content := []byte("0123456789abcdefghij")
+
+	t.Run("Put and read", func(t *testing.T) {
+		if err := store.Put(ctx, "ab/blob", bytes.NewReader(content), int64(len(content))); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		blob, err := store.Open(ctx, "ab/blob")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		defer blob.Close()
+
+		got, err := io.ReadAll(blob)
+		assert.Nil(t, err)
+		assert.Equal(t, content, got)
+		assert.Equal(t, int64(len(content)), blob.Size())
+	})
+
+	t.Run("Seek and read", func(t *testing.T) {
+		blob, err := store.Open(ctx, "ab/blob")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		defer blob.Close()
+
+		buf := make([]byte, 5)
+		_, err = blob.Seek(10, io.SeekStart)
+		assert.Nil(t, err)
+		_, err = io.ReadFull(blob, buf)
+		assert.Nil(t, err)
+		assert.Equal(t, "abcde", string(buf))
+
+		_, err = blob.Seek(-3, io.SeekEnd)
+		assert.Nil(t, err)
+		rest, err := io.ReadAll(blob)
+		assert.Nil(t, err)
+		assert.Equal(t, "hij", string(rest))
+	})
+
+	t.Run("Serve range", func(t *testing.T) {
+		blob, err := store.Open(ctx, "ab/blob")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		defer blob.Close()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Range", "bytes=2-5")
+		rec := httptest.NewRecorder()
+		http.ServeContent(rec, req, "", time.Time{}, blob)
+
+		assert.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, "2345", rec.Body.String())
+		assert.Equal(t, "bytes 2-5/20", rec.Header().Get("Content-Range"))
+	})
+
+	t.Run("Short content", func(t *testing.T) {
+		err := store.Put(ctx, "ab/short", strings.NewReader("abc"), 10)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.Nil(t, store.Delete(ctx, "ab/blob"))
+		assert.Nil(t, store.Delete(ctx, "ab/blob"))
+
+		_, err := store.Open(ctx, "ab/blob")
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("Invalid key", func(t *testing.T) {
+		for _, key := range []string{"", "/abs", "../escape", "a/../../b"} {
+			_, err := store.Open(ctx, key)
+			assert.True(t, errors.Is(err, ErrInvalidKey), key)
+		}
+	})
+}
+
+func TestFilesystemStore(t *testing.T) {
+	store, err := NewFilesystemStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	testBlobStore(t, store)
+}
+
+// fakeS3 is an in memory stand-in of an S3 compatible storage
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=access/") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(body)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.objects[r.URL.Path] = body
+
+	case http.MethodGet, http.MethodHead:
+		object, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(object))
+
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "attachments",
+		AccessKey: "access",
+		SecretKey: "secret",
+	}, server.Client())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	testBlobStore(t, store)
+
+	t.Run("Path style key", func(t *testing.T) {
+		err := store.Put(context.Background(), "cd/blob", strings.NewReader("x"), 1)
+		assert.Nil(t, err)
+		assert.Contains(t, fake.objects, "/attachments/cd/blob")
+	})
+}
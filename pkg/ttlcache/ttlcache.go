@@ -0,0 +1,88 @@
+// Package ttlcache is an in-memory cache whose entries expire
+// after a time to live.
+package ttlcache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Cache is safe for concurrent use. Expired entries are not returned,
+// they are removed from memory by DeleteExpired.
+type Cache[K comparable, V any] struct {
+	mu      sync.RWMutex
+	entries map[K]entry[V]
+	now     func() time.Time
+}
+
+func New[K comparable, V any]() *Cache[K, V] {
+	return &Cache[K, V]{
+		entries: make(map[K]entry[V]),
+		now:     time.Now,
+	}
+}
+
+// Set stores value under key until ttl passes,
+// replacing any value already stored
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) {
+	c.SetUntil(key, value, c.now().Add(ttl))
+}
+
+// SetUntil stores value under key until expiresAt,
+// replacing any value already stored
+func (c *Cache[K, V]) SetUntil(key K, value V, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry[V]{value: value, expiresAt: expiresAt}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+
+	return e.value, true
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// DeleteExpired removes the expired entries and returns how many were removed
+func (c *Cache[K, V]) DeleteExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	n := 0
+	for key, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, key)
+			n++
+		}
+	}
+
+	return n
+}
+
+// Len returns the number of entries, including expired ones not yet deleted
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.entries)
+}
@@ -0,0 +1,101 @@
+package ttlcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestCache() (*Cache[string, int], *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)}
+	cache := New[string, int]()
+	cache.now = clock.Now
+
+	return cache, clock
+}
+
+func TestCache_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		elapsed time.Duration
+		wantOK  bool
+	}{
+		{name: "fresh", ttl: time.Minute, elapsed: 0, wantOK: true},
+		{name: "before expiry", ttl: time.Minute, elapsed: 59 * time.Second, wantOK: true},
+		{name: "at expiry", ttl: time.Minute, elapsed: time.Minute, wantOK: false},
+		{name: "after expiry", ttl: time.Minute, elapsed: time.Hour, wantOK: false},
+		{name: "zero ttl", ttl: 0, elapsed: 0, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, clock := newTestCache()
+			cache.Set("key", 1, tt.ttl)
+			clock.now = clock.now.Add(tt.elapsed)
+
+			got, ok := cache.Get("key")
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, 1, got)
+			} else {
+				assert.Zero(t, got)
+			}
+		})
+	}
+}
+
+func TestCache_SetReplaces(t *testing.T) {
+	cache, clock := newTestCache()
+	cache.Set("key", 1, time.Minute)
+	cache.Set("key", 2, time.Hour)
+	clock.now = clock.now.Add(30 * time.Minute)
+
+	got, ok := cache.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, 2, got)
+}
+
+func TestCache_Missing(t *testing.T) {
+	cache, _ := newTestCache()
+
+	_, ok := cache.Get("key")
+	assert.False(t, ok)
+}
+
+func TestCache_Delete(t *testing.T) {
+	cache, _ := newTestCache()
+	cache.Set("key", 1, time.Minute)
+	cache.Delete("key")
+
+	_, ok := cache.Get("key")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCache_DeleteExpired(t *testing.T) {
+	cache, clock := newTestCache()
+	cache.Set("short", 1, time.Minute)
+	cache.Set("long", 2, time.Hour)
+	cache.SetUntil("past", 3, clock.now.Add(-time.Second))
+
+	assert.Equal(t, 1, cache.DeleteExpired())
+	assert.Equal(t, 2, cache.Len())
+
+	clock.now = clock.now.Add(time.Minute)
+	assert.Equal(t, 1, cache.DeleteExpired())
+	assert.Equal(t, 1, cache.Len())
+
+	got, ok := cache.Get("long")
+	assert.True(t, ok)
+	assert.Equal(t, 2, got)
+}